@@ -0,0 +1,219 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrFieldNotAliased = errors.New("field has no alias")
+
+// Storage side of a public field name
+type Alias struct {
+	Name      string                                       // internal name, eg: "c.full_name", the public name is kept when empty
+	Transform func(value interface{}) (interface{}, error) // optional, converts each filter and cursor value, eg: an enum label to its stored int
+}
+
+// Maps the field names sent by clients to the names the storage knows them by,
+// eg: "createdAt" -> "created_at" and "customer.name" -> "c.full_name"
+type Aliases map[string]Alias
+
+// Rewrites the fields of the filters, expression, orders, cursor and field paths of q from their public names to
+// their internal ones, transforming filter values into Filter.Typed, and into Filter.Value for text operators, eg: contains. Fields without an alias are reported, so
+// internal names sent by clients never reach the storage. Errors name the public fields.
+// The query should still be validated with a Schema of public names, and links encoded from the original one.
+func (a Aliases) Rewrite(q Query) (Query, error) {
+	filters := make([]Filter, 0, len(q.Filters))
+	for _, f := range q.Filters {
+		rewritten, err := a.RewriteFilter(f)
+		if err != nil {
+			return Query{}, err
+		}
+		filters = append(filters, rewritten)
+	}
+	q.Filters = filters
+
+	if q.Expr != nil {
+		if err := q.Expr.Validate(); err != nil {
+			return Query{}, err
+		}
+
+		expr, err := a.rewriteExpr(*q.Expr)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Expr = &expr
+	}
+
+	orders, err := a.rewriteOrders(q.Orders)
+	if err != nil {
+		return Query{}, err
+	}
+	q.Orders = orders
+
+	if q.Pagination.Cursor != nil {
+		cursor, err := a.rewriteCursor(*q.Pagination.Cursor)
+		if err != nil {
+			return Query{}, err
+		}
+		q.Pagination.Cursor = &cursor
+	}
+
+	if q.Fields != nil {
+		fields := make([]string, 0, len(q.Fields))
+		for _, path := range q.Fields {
+			alias, err := a.alias(path)
+			if err != nil {
+				return Query{}, err
+			}
+			fields = append(fields, alias.Name)
+		}
+		q.Fields = fields
+	}
+
+	return q, nil
+}
+
+// Same as Rewrite, but for a single filter
+func (a Aliases) RewriteFilter(f Filter) (Filter, error) {
+	alias, err := a.alias(f.Field)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if alias.Transform != nil && f.Operation.Arity() != ArityNone {
+		f.Typed, err = alias.transform(f)
+		if err != nil {
+			return Filter{}, fmt.Errorf("%w: %q for field %q: %v", ErrInvalidFilterValue, f.Value, f.Field, err)
+		}
+
+		// text operators match Filter.Value, so it must hold the transformed text too
+		if f.Operation.isText() {
+			s, ok := f.Typed.(string)
+			if !ok {
+				return Filter{}, fmt.Errorf("%w: %q for field %q: %s takes text, got %T", ErrInvalidFilterValue, f.Value, f.Field, f.Operation, f.Typed)
+			}
+			f.Value = s
+		}
+	}
+
+	f.Field = alias.Name
+	return f, nil
+}
+
+// Same as Rewrite, but for a single order
+func (a Aliases) RewriteOrder(o Order) (Order, error) {
+	alias, err := a.alias(o.Field)
+	if err != nil {
+		return Order{}, err
+	}
+
+	o.Field = alias.Name
+	return o, nil
+}
+
+// Maps every internal name to itself, to be used as the fields map of a translator reading rewritten queries,
+// eg: sql.Builder{Columns: aliases.Names()}, so it still writes nothing but the aliased names
+func (a Aliases) Names() map[string]string {
+	names := make(map[string]string, len(a))
+	for public := range a {
+		alias, _ := a.alias(public)
+		names[alias.Name] = alias.Name
+	}
+	return names
+}
+
+// The alias of a public field, its Name defaulting to the public name
+func (a Aliases) alias(field string) (Alias, error) {
+	alias, ok := a[field]
+	if !ok {
+		return Alias{}, fmt.Errorf("%w: %q", ErrFieldNotAliased, field)
+	}
+
+	if alias.Name == "" {
+		alias.Name = field
+	}
+	return alias, nil
+}
+
+// Transforms the typed value of the filter, or each of them for list operators
+func (alias Alias) transform(f Filter) (interface{}, error) {
+	if !f.Operation.isList() {
+		return alias.Transform(f.TypedValue())
+	}
+
+	values := f.TypedValues()
+	for i, v := range values {
+		transformed, err := alias.Transform(v)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = transformed
+	}
+	return values, nil
+}
+
+func (a Aliases) rewriteExpr(e FilterExpr) (FilterExpr, error) {
+	if e.Kind == FilterExprLeaf {
+		f, err := a.RewriteFilter(*e.Filter)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		return Leaf(f), nil
+	}
+
+	children := make([]FilterExpr, 0, len(e.Children))
+	for _, c := range e.Children {
+		child, err := a.rewriteExpr(c)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, child)
+	}
+
+	e.Children = children
+	return e, nil
+}
+
+func (a Aliases) rewriteOrders(orders []Order) ([]Order, error) {
+	if orders == nil {
+		return nil, nil
+	}
+
+	rewritten := make([]Order, 0, len(orders))
+	for _, o := range orders {
+		order, err := a.RewriteOrder(o)
+		if err != nil {
+			return nil, err
+		}
+		rewritten = append(rewritten, order)
+	}
+	return rewritten, nil
+}
+
+// Rewrites the cursor orders, transforming the cursor values as filter values are, as keyset
+// conditions compare them to the storage values
+func (a Aliases) rewriteCursor(c Cursor) (Cursor, error) {
+	orders, err := a.rewriteOrders(c.Orders)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	values := make([]interface{}, len(c.Values))
+	copy(values, c.Values)
+
+	for i, o := range c.Orders {
+		alias, _ := a.alias(o.Field)
+		if alias.Transform == nil || i >= len(values) {
+			continue
+		}
+
+		values[i], err = alias.Transform(values[i])
+		if err != nil {
+			return Cursor{}, fmt.Errorf("%w: %v for field %q: %v", ErrInvalidCursor, c.Values[i], o.Field, err)
+		}
+	}
+
+	c.Orders = orders
+	c.Values = values
+	return c, nil
+}
@@ -0,0 +1,153 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var statusIDs = map[interface{}]int{"open": 1, "closed": 2}
+
+var testAliases = Aliases{
+	"price":         {},
+	"createdAt":     {Name: "created_at"},
+	"customer.name": {Name: "c.full_name"},
+	"status": {Name: "status_id", Transform: func(v interface{}) (interface{}, error) {
+		id, ok := statusIDs[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown status %v", v)
+		}
+		return id, nil
+	}},
+	"email": {Transform: func(v interface{}) (interface{}, error) {
+		return strings.ToLower(fmt.Sprint(v)), nil
+	}},
+}
+
+func TestAliasesRewrite(t *testing.T) {
+	expr := And(
+		Or(
+			Leaf(Filter{Field: "customer.name", Operation: FilterOperatorEqual, Value: "a"}),
+			Leaf(Filter{Field: "status", Operation: FilterOperatorIsNull}),
+		),
+		Not(Leaf(Filter{Field: "status", Operation: FilterOperatorIn, Value: "open;closed"})),
+	)
+
+	tests := []struct {
+		name    string
+		q       Query
+		want    Query
+		wantErr error
+	}{
+		{
+			name: "should rewrite filters, orders and fields",
+			q: Query{
+				Filters: []Filter{
+					{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)},
+					{Field: "status", Operation: FilterOperatorEqual, Value: "open"},
+				},
+				Orders: []Order{{Field: "createdAt", Asc: false}},
+				Fields: []string{"customer.name"},
+				Search: "x",
+			},
+			want: Query{
+				Filters: []Filter{
+					{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)},
+					{Field: "status_id", Operation: FilterOperatorEqual, Value: "open", Typed: 1},
+				},
+				Orders: []Order{{Field: "created_at", Asc: false}},
+				Fields: []string{"c.full_name"},
+				Search: "x",
+			},
+		},
+		{
+			name: "should rewrite expressions leaves and list values",
+			q:    Query{Filters: []Filter{}, Expr: &expr},
+			want: Query{
+				Filters: []Filter{},
+				Expr: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{
+					Or(
+						Leaf(Filter{Field: "c.full_name", Operation: FilterOperatorEqual, Value: "a"}),
+						Leaf(Filter{Field: "status_id", Operation: FilterOperatorIsNull}),
+					),
+					Not(Leaf(Filter{Field: "status_id", Operation: FilterOperatorIn, Value: "open;closed", Typed: []interface{}{1, 2}})),
+				}},
+			},
+		},
+		{
+			name: "should rewrite cursor orders and values",
+			q: Query{
+				Orders: []Order{{Field: "status", Asc: true}},
+				Pagination: Paginable{Limit: 10, Cursor: &Cursor{
+					Orders: []Order{{Field: "status", Asc: true}},
+					Values: []interface{}{"closed"},
+				}},
+			},
+			want: Query{
+				Filters: []Filter{},
+				Orders:  []Order{{Field: "status_id", Asc: true}},
+				Pagination: Paginable{Limit: 10, Cursor: &Cursor{
+					Orders: []Order{{Field: "status_id", Asc: true}},
+					Values: []interface{}{2},
+				}},
+			},
+		},
+		{
+			name: "should rewrite the values of text operators",
+			q:    Query{Filters: []Filter{{Field: "email", Operation: FilterOperatorContains, Value: "Smith@"}}},
+			want: Query{Filters: []Filter{{Field: "email", Operation: FilterOperatorContains, Value: "smith@", Typed: "smith@"}}},
+		},
+		{
+			name:    "should reject transforms to other than text for text operators",
+			q:       Query{Filters: []Filter{{Field: "status", Operation: FilterOperatorContains, Value: "open"}}},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:    "should reject fields without alias",
+			q:       Query{Orders: []Order{{Field: "created_at", Asc: true}}},
+			wantErr: ErrFieldNotAliased,
+		},
+		{
+			name:    "should reject values the transform can't convert",
+			q:       Query{Filters: []Filter{{Field: "status", Operation: FilterOperatorEqual, Value: "lost"}}},
+			wantErr: ErrInvalidFilterValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testAliases.Rewrite(tt.q)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestAliasesErrorsUsePublicNames(t *testing.T) {
+	expect := assert.New(t)
+
+	_, err := testAliases.RewriteFilter(Filter{Field: "status", Operation: FilterOperatorEqual, Value: "lost"})
+	expect.Contains(err.Error(), `"status"`)
+	expect.NotContains(err.Error(), "status_id")
+
+	_, err = testAliases.RewriteOrder(Order{Field: "password"})
+	expect.Contains(err.Error(), `"password"`)
+}
+
+func TestAliasesNames(t *testing.T) {
+	assert.Equal(t, map[string]string{
+		"price":       "price",
+		"created_at":  "created_at",
+		"c.full_name": "c.full_name",
+		"status_id":   "status_id",
+		"email":       "email",
+	}, testAliases.Names())
+}
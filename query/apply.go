@@ -0,0 +1,399 @@
+package query
+
+import (
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Result of a condition under SQL three-valued logic, where comparing with NULL is unknown
+type truth int8
+
+const (
+	truthUnknown truth = iota
+	truthFalse
+	truthTrue
+)
+
+func truthOf(b bool) truth {
+	if b {
+		return truthTrue
+	}
+	return truthFalse
+}
+
+// Filters, sorts and paginates items in memory the way the sql package has a database do it, for small
+// cached datasets. Fields are resolved by their json names, nested paths included, and fields that are
+// nil or missing on an item are NULL: they only match isNull and sort after every value, as on Postgres.
+// Filter values of queries parsed without a schema are converted to the type of the item field.
+// Filters on values of other types, eg: slices, never match, and neither does any item when the expression is invalid.
+func Apply[T any](items []T, q Query) []T {
+	if q.Expr != nil && q.Expr.Validate() != nil {
+		return []T{}
+	}
+	expr := q.FilterExpr()
+
+	orders := q.Orders
+	if cursor := q.Pagination.Cursor; cursor != nil {
+		if !cursor.Matches(q.Orders) {
+			return []T{}
+		}
+
+		expr = And(expr, keysetExpr(cursor))
+		orders = cursor.FetchOrders()
+	}
+
+	matcher := newMatcher(expr)
+
+	type row struct {
+		item T
+		keys []interface{}
+	}
+
+	rows := []row{}
+	for _, item := range items {
+		v := reflect.ValueOf(item)
+		if matcher.eval(v) != truthTrue {
+			continue
+		}
+
+		keys := make([]interface{}, 0, len(orders))
+		for _, o := range orders {
+			keys = append(keys, fieldValue(v, o.Field))
+		}
+		rows = append(rows, row{item: item, keys: keys})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, o := range orders {
+			c := compareForSort(rows[i].keys[k], rows[j].keys[k])
+			if c == 0 {
+				continue
+			}
+			if o.Asc {
+				return c < 0
+			}
+			return c > 0
+		}
+		return false
+	})
+
+	offset := q.Pagination.Offset
+	if q.Pagination.Cursor != nil || offset < 0 {
+		offset = 0
+	}
+	if offset > len(rows) {
+		offset = len(rows)
+	}
+
+	// compared to the rows left rather than added to the offset, which could overflow
+	end := len(rows)
+	if limit := q.Pagination.Limit; limit >= 0 && limit < end-offset {
+		end = offset + limit
+	}
+
+	result := make([]T, 0, end-offset)
+	for _, r := range rows[offset:end] {
+		result = append(result, r.item)
+	}
+	return result
+}
+
+// Same predicate as the sql package keyset, eg: "price > 10 OR (price = 10 AND id > 7)"
+func keysetExpr(cursor *Cursor) FilterExpr {
+	orders := cursor.FetchOrders()
+	alternatives := []FilterExpr{}
+
+	for i, o := range orders {
+		parts := []FilterExpr{}
+		for j := 0; j < i; j++ {
+			parts = append(parts, Leaf(Filter{Field: orders[j].Field, Operation: FilterOperatorEqual, Typed: cursor.Values[j]}))
+		}
+
+		op := FilterOperatorLessThan
+		if o.Asc {
+			op = FilterOperatorGreaterThan
+		}
+		parts = append(parts, Leaf(Filter{Field: o.Field, Operation: op, Typed: cursor.Values[i]}))
+
+		alternatives = append(alternatives, And(parts...))
+	}
+
+	return Or(alternatives...)
+}
+
+// Evaluates an expression, compiling its regular expressions only once
+type matcher struct {
+	expr    FilterExpr
+	regexes map[string]*regexp.Regexp
+}
+
+func newMatcher(expr FilterExpr) *matcher {
+	m := &matcher{expr: expr, regexes: map[string]*regexp.Regexp{}}
+
+	for _, f := range expr.Filters() {
+		if f.Operation == FilterOperatorRegex {
+			// invalid patterns stay nil and never match
+			m.regexes[f.Value], _ = regexp.Compile(f.Value)
+		}
+	}
+
+	return m
+}
+
+func (m *matcher) eval(item reflect.Value) truth {
+	return m.evalExpr(m.expr, item)
+}
+
+func (m *matcher) evalExpr(e FilterExpr, item reflect.Value) truth {
+	switch e.Kind {
+	case FilterExprLeaf:
+		return m.evalFilter(*e.Filter, fieldValue(item, e.Filter.Field))
+	case FilterExprNot:
+		switch m.evalExpr(e.Children[0], item) {
+		case truthTrue:
+			return truthFalse
+		case truthFalse:
+			return truthTrue
+		}
+		return truthUnknown
+	case FilterExprOr:
+		result := truthFalse
+		for _, child := range e.Children {
+			switch m.evalExpr(child, item) {
+			case truthTrue:
+				return truthTrue
+			case truthUnknown:
+				result = truthUnknown
+			}
+		}
+		return result
+	}
+
+	result := truthTrue
+	for _, child := range e.Children {
+		switch m.evalExpr(child, item) {
+		case truthFalse:
+			return truthFalse
+		case truthUnknown:
+			result = truthUnknown
+		}
+	}
+	return result
+}
+
+func (m *matcher) evalFilter(f Filter, field interface{}) truth {
+	switch f.Operation {
+	case FilterOperatorIsNull:
+		return truthOf(field == nil)
+	case FilterOperatorNotNull:
+		return truthOf(field != nil)
+	}
+
+	if field == nil {
+		return truthUnknown
+	}
+
+	switch f.Operation {
+	case FilterOperatorIn, FilterOperatorNotIn:
+		found := false
+		for _, v := range filterValues(f, field) {
+			if c, ok := compare(field, v); ok && c == 0 {
+				found = true
+			} else if !ok {
+				return truthUnknown
+			}
+		}
+		return truthOf(found == (f.Operation == FilterOperatorIn))
+	case FilterOperatorBetween:
+		values := filterValues(f, field)
+		if len(values) != 2 {
+			return truthUnknown
+		}
+		low, okLow := compare(field, values[0])
+		high, okHigh := compare(field, values[1])
+		if !okLow || !okHigh {
+			return truthUnknown
+		}
+		return truthOf(low >= 0 && high <= 0)
+	}
+
+	s, isString := field.(string)
+
+	switch f.Operation {
+	case FilterOperatorStartsWith:
+		return truthOf(isString && strings.HasPrefix(s, f.Value))
+	case FilterOperatorEndsWith:
+		return truthOf(isString && strings.HasSuffix(s, f.Value))
+	case FilterOperatorContains:
+		return truthOf(isString && strings.Contains(s, f.Value))
+	case FilterOperatorEqualFold:
+		return truthOf(isString && strings.ToLower(s) == strings.ToLower(f.Value))
+	case FilterOperatorContainsFold:
+		return truthOf(isString && strings.Contains(strings.ToLower(s), strings.ToLower(f.Value)))
+	case FilterOperatorRegex:
+		regex := m.regexes[f.Value]
+		return truthOf(isString && regex != nil && regex.MatchString(s))
+	}
+
+	value, ok := filterValue(f.Typed, f.Value, field)
+	if !ok {
+		return truthUnknown
+	}
+
+	c, ok := compare(field, value)
+	if !ok {
+		return truthUnknown
+	}
+
+	switch f.Operation {
+	case FilterOperatorEqual:
+		return truthOf(c == 0)
+	case FilterOperatorNotEqual:
+		return truthOf(c != 0)
+	case FilterOperatorLessThan:
+		return truthOf(c < 0)
+	case FilterOperatorLessThanOrEqual:
+		return truthOf(c <= 0)
+	case FilterOperatorGreaterThan:
+		return truthOf(c > 0)
+	case FilterOperatorGretherThanOrEqual:
+		return truthOf(c >= 0)
+	}
+
+	return truthFalse
+}
+
+// The value of the field on the item as int64, float64, Decimal, string, bool or time.Time, nil meaning NULL
+func fieldValue(item reflect.Value, path string) interface{} {
+	v, ok := lookup(item, path)
+	if !ok {
+		return nil
+	}
+
+	value, err := cursorValueOf(v)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+func filterValues(f Filter, field interface{}) []interface{} {
+	values := []interface{}{}
+
+	for _, v := range f.TypedValues() {
+		raw, _ := v.(string)
+		if value, ok := filterValue(v, raw, field); ok {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+// The typed value when set, else the raw value converted to the type of the field, the way
+// a database casts a string parameter to the column type
+func filterValue(typed interface{}, raw string, field interface{}) (interface{}, bool) {
+	if s, isString := typed.(string); isString {
+		raw = s
+	} else if typed != nil {
+		return typed, true
+	}
+
+	var value interface{}
+	var err error
+
+	switch field.(type) {
+	case int64, float64:
+		value, err = strconv.ParseFloat(raw, 64)
+	case Decimal:
+		value, err = parseDecimal(raw)
+	case bool:
+		value, err = strconv.ParseBool(raw)
+	case time.Time:
+		value, err = parseTime(raw)
+		if err != nil {
+			value, err = parseDate(raw)
+		}
+	default:
+		value = raw
+	}
+
+	return value, err == nil
+}
+
+// Compares values of compatible types, numbers of different types included
+func compare(a, b interface{}) (int, bool) {
+	switch x := a.(type) {
+	case string:
+		y, ok := b.(string)
+		return strings.Compare(x, y), ok
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		return boolToInt(x) - boolToInt(y), true
+	case time.Time:
+		y, ok := b.(time.Time)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x.Before(y):
+			return -1, true
+		case x.After(y):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	x, okA := toRat(a)
+	y, okB := toRat(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// Same as compare, but NULL is greater than every value and values that can't be compared are equal
+func compareForSort(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+
+	c, _ := compare(a, b)
+	return c
+}
+
+func toRat(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(n), true
+	case float64:
+		r := new(big.Rat)
+		if r.SetFloat64(n) == nil {
+			return nil, false
+		}
+		return r, true
+	case Decimal:
+		return new(big.Rat).SetString(string(n))
+	}
+	return nil, false
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
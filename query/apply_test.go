@@ -0,0 +1,132 @@
+package query
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type applyTestVendor struct {
+	Name string `json:"name"`
+}
+
+type applyTestProduct struct {
+	ID        int              `json:"id"`
+	Name      string           `json:"name"`
+	Price     float64          `json:"price"`
+	Stock     *int             `json:"stock"`
+	CreatedAt time.Time        `json:"createdAt"`
+	Vendor    *applyTestVendor `json:"vendor"`
+}
+
+// Fixtures shared with the sql package tests, so Apply and the SQL rendered for the same query agree
+type applyTestFixtures struct {
+	Rows  []applyTestProduct `json:"rows"`
+	Cases []struct {
+		Name     string `json:"name"`
+		Query    string `json:"query"`
+		Grouping bool   `json:"grouping"`
+		IDs      []int  `json:"ids"`
+	} `json:"cases"`
+}
+
+func loadApplyTestFixtures(t *testing.T) applyTestFixtures {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", "products.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fixtures applyTestFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+	return fixtures
+}
+
+func applyTestIDs(products []applyTestProduct) []int {
+	ids := []int{}
+	for _, p := range products {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func TestApply(t *testing.T) {
+	fixtures := loadApplyTestFixtures(t)
+
+	for _, tt := range fixtures.Cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			q, err := ParseValues(ParseQueryString(tt.Query), Options{Strict: true, Grouping: tt.Grouping})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.IDs, applyTestIDs(Apply(fixtures.Rows, q)))
+		})
+	}
+}
+
+func TestApplyWithSchema(t *testing.T) {
+	fixtures := loadApplyTestFixtures(t)
+	schema := &Schema{Fields: map[string]Field{
+		"price": {Type: FieldTypeDecimal, Operators: []FilterOperator{FilterOperatorLessThan}},
+		"name":  {Operators: []FilterOperator{FilterOperatorRegex}},
+	}}
+
+	q, err := ParseValues(ParseQueryString("filters=price[lt]20.5,name[regex]^S.*l$"), Options{Schema: schema})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2}, applyTestIDs(Apply(fixtures.Rows, q)))
+}
+
+func TestApplyWithCursor(t *testing.T) {
+	fixtures := loadApplyTestFixtures(t)
+	orders := []Order{{Field: "price", Asc: true}, {Field: "id", Asc: true}}
+
+	next := Cursor{Orders: orders, Values: []interface{}{int64(20), int64(2)}}
+	got := Apply(fixtures.Rows, Query{Orders: orders, Pagination: Paginable{Limit: 2, Cursor: &next}})
+	assert.Equal(t, []int{5, 1}, applyTestIDs(got))
+
+	// fetched in reverse, as for the sql package
+	prev := Cursor{Orders: orders, Values: []interface{}{int64(50), int64(1)}, Backward: true}
+	got = Apply(fixtures.Rows, Query{Orders: orders, Pagination: Paginable{Limit: 2, Cursor: &prev}})
+	assert.Equal(t, []int{5, 2}, applyTestIDs(got))
+
+	got = Apply(fixtures.Rows, Query{Orders: orders[:1], Pagination: Paginable{Limit: 2, Cursor: &next}})
+	assert.Empty(t, got, "should not match cursors of other orders")
+}
+
+func TestApplyOnMaps(t *testing.T) {
+	items := []map[string]interface{}{
+		{"id": 1, "tags": []string{"a"}},
+		{"id": 2, "enabled": true},
+	}
+
+	q := Query{Filters: []Filter{{Field: "enabled", Operation: FilterOperatorEqual, Value: "true"}}, Pagination: Paginable{Limit: 10}}
+	assert.Equal(t, []map[string]interface{}{items[1]}, Apply(items, q))
+
+	q = Query{Filters: []Filter{{Field: "tags", Operation: FilterOperatorEqual, Value: "a"}}, Pagination: Paginable{Limit: 10}}
+	assert.Empty(t, Apply(items, q), "should not match unsupported types")
+}
+
+func TestApplyWithLargePagination(t *testing.T) {
+	items := []int{1, 2, 3}
+
+	assert.Equal(t, []int{2, 3}, Apply(items, Query{Pagination: Paginable{Limit: math.MaxInt, Offset: 1}}))
+	assert.Equal(t, []int{}, Apply(items, Query{Pagination: Paginable{Limit: math.MaxInt, Offset: math.MaxInt}}))
+	assert.Equal(t, []int{1}, Apply(items, Query{Pagination: Paginable{Limit: 1}}))
+}
+
+func TestApplyWithEmptyExpr(t *testing.T) {
+	fixtures := loadApplyTestFixtures(t)
+
+	assert.Len(t, Apply(fixtures.Rows, Query{Pagination: Paginable{Limit: 10}}), len(fixtures.Rows), "should match every item without filters")
+
+	for _, expr := range []FilterExpr{And(), Or(), Not(Or())} {
+		expr := expr
+		assert.Empty(t, Apply(fixtures.Rows, Query{Expr: &expr, Pagination: Paginable{Limit: 10}}), "should not match any item for %s", expr.Kind)
+	}
+}
@@ -0,0 +1,277 @@
+package query
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrCursorMismatch = errors.New("cursor does not match order")
+	ErrMissingSecret  = errors.New("cursor codec has no secret")
+)
+
+// Position of a keyset paginated page: the values of the last (or first, when going backward)
+// row seen for each of the orders. Order fields must be non null and the last order must be a
+// unique tiebreaker, see WithTiebreaker.
+type Cursor struct {
+	Orders   []Order       // orders the cursor was built for
+	Values   []interface{} // values of each order field on the row, as int64, float64, Decimal, string, bool or time.Time
+	Backward bool          // if true, the cursor points to the page before the row
+}
+
+// Tags the type of each value so cursors keep their values types after a JSON round trip
+type cursorValue struct {
+	Int     *int64     `json:"i,omitempty"`
+	Float   *float64   `json:"f,omitempty"`
+	Decimal *Decimal   `json:"d,omitempty"`
+	String  *string    `json:"s,omitempty"`
+	Bool    *bool      `json:"b,omitempty"`
+	Time    *time.Time `json:"t,omitempty"`
+}
+
+type cursorJSON struct {
+	Orders   []Order       `json:"orders"`
+	Values   []cursorValue `json:"values"`
+	Backward bool          `json:"backward,omitempty"`
+}
+
+func (c Cursor) MarshalJSON() ([]byte, error) {
+	values := make([]cursorValue, 0, len(c.Values))
+
+	for _, v := range c.Values {
+		cv := cursorValue{}
+
+		switch t := v.(type) {
+		case int64:
+			cv.Int = &t
+		case float64:
+			cv.Float = &t
+		case Decimal:
+			cv.Decimal = &t
+		case string:
+			cv.String = &t
+		case bool:
+			cv.Bool = &t
+		case time.Time:
+			cv.Time = &t
+		case nil:
+		default:
+			return nil, fmt.Errorf("%w: unsupported value type %T", ErrInvalidCursor, v)
+		}
+
+		values = append(values, cv)
+	}
+
+	return json.Marshal(cursorJSON{Orders: c.Orders, Values: values, Backward: c.Backward})
+}
+
+func (c *Cursor) UnmarshalJSON(data []byte) error {
+	var cj cursorJSON
+	if err := json.Unmarshal(data, &cj); err != nil {
+		return err
+	}
+
+	values := make([]interface{}, 0, len(cj.Values))
+	for _, cv := range cj.Values {
+		var v interface{}
+
+		switch {
+		case cv.Int != nil:
+			v = *cv.Int
+		case cv.Float != nil:
+			v = *cv.Float
+		case cv.Decimal != nil:
+			v = *cv.Decimal
+		case cv.String != nil:
+			v = *cv.String
+		case cv.Bool != nil:
+			v = *cv.Bool
+		case cv.Time != nil:
+			v = *cv.Time
+		}
+
+		values = append(values, v)
+	}
+
+	*c = Cursor{Orders: cj.Orders, Values: values, Backward: cj.Backward}
+	return nil
+}
+
+// Returns whether the cursor was built for the orders
+func (c *Cursor) Matches(orders []Order) bool {
+	return len(c.Values) == len(c.Orders) && reflect.DeepEqual(c.Orders, orders)
+}
+
+// Orders to fetch the page with, which are the cursor orders reversed when going backward.
+// Rows of a backward page are fetched in reverse and must be reversed back before responding.
+func (c *Cursor) FetchOrders() []Order {
+	orders := make([]Order, 0, len(c.Orders))
+
+	for _, o := range c.Orders {
+		if c.Backward {
+			o.Asc = !o.Asc
+		}
+		orders = append(orders, o)
+	}
+
+	return orders
+}
+
+// Encodes cursors as opaque strings signed with HMAC-SHA256, so clients can't forge or tamper with them
+type CursorCodec struct {
+	Secret []byte // required, Encode and Decode fail with ErrMissingSecret when empty
+}
+
+func (c CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// eg: Cursor{...} -> "eyJvcmRlcnMiOltdfQ.c2lnbmF0dXJl"
+func (c CursorCodec) Encode(cursor Cursor) (string, error) {
+	if len(c.Secret) == 0 {
+		return "", ErrMissingSecret
+	}
+
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+func (c CursorCodec) Decode(s string) (Cursor, error) {
+	if len(c.Secret) == 0 {
+		return Cursor{}, ErrMissingSecret
+	}
+
+	encodedPayload, encodedSignature, ok := strings.Cut(s, ".")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, c.sign(payload)) {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// Appends the tiebreaker, usually an unique id, to the orders when they don't sort by it yet
+func WithTiebreaker(orders []Order, tiebreaker Order) []Order {
+	for _, o := range orders {
+		if o.Field == tiebreaker.Field {
+			return orders
+		}
+	}
+
+	return append(append([]Order{}, orders...), tiebreaker)
+}
+
+// Builds the cursor of the page after the one ending at row.
+// Row is a struct or a map, and order fields are resolved by their json names.
+func NextCursor(row interface{}, orders []Order) (Cursor, error) {
+	return newCursor(row, orders, false)
+}
+
+// Builds the cursor of the page before the one starting at row
+func PrevCursor(row interface{}, orders []Order) (Cursor, error) {
+	return newCursor(row, orders, true)
+}
+
+func newCursor(row interface{}, orders []Order, backward bool) (Cursor, error) {
+	values := make([]interface{}, 0, len(orders))
+
+	for _, o := range orders {
+		v, ok := lookup(reflect.ValueOf(row), o.Field)
+		if !ok {
+			return Cursor{}, fmt.Errorf("%w: row has no field %q", ErrInvalidCursor, o.Field)
+		}
+
+		value, err := cursorValueOf(v)
+		if err != nil {
+			return Cursor{}, fmt.Errorf("%w: field %q: %v", ErrInvalidCursor, o.Field, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return Cursor{Orders: orders, Values: values, Backward: backward}, nil
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	decimalType = reflect.TypeOf(Decimal(""))
+)
+
+func cursorValueOf(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	switch {
+	case v.Type() == timeType:
+		return v.Interface(), nil
+	case v.Type() == decimalType:
+		return v.Interface(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported value type %s", v.Type())
+}
+
+// Same as GetPaginationFromQuery, but reads the page position from the cursor key instead of the offset.
+// The returned Paginable has no cursor when the query has none, meaning the first page.
+func GetCursorPaginationFromQuery(ctx *fiber.Ctx, codec CursorCodec) (Paginable, error) {
+	pagination := Paginable{
+		Limit: getPositiveIntFromQueryWithFallback(ctx, string(QueryKeyLimit), 10),
+	}
+
+	encoded := ctx.Query(string(QueryKeyCursor))
+	if encoded == "" {
+		return pagination, nil
+	}
+
+	cursor, err := codec.Decode(encoded)
+	if err != nil {
+		return Paginable{}, err
+	}
+
+	pagination.Cursor = &cursor
+	return pagination, nil
+}
@@ -0,0 +1,150 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type cursorTestCustomer struct {
+	Name string `json:"name"`
+}
+
+type cursorTestRow struct {
+	ID        uint                `json:"id"`
+	Price     float32             `json:"price"`
+	Total     Decimal             `json:"total"`
+	CreatedAt time.Time           `json:"createdAt"`
+	Customer  *cursorTestCustomer `json:"customer"`
+	Secret    string              `json:"-"`
+}
+
+func TestCursorCodec(t *testing.T) {
+	expect := assert.New(t)
+	codec := CursorCodec{Secret: []byte("secret")}
+
+	cursor := Cursor{
+		Orders: []Order{{Field: "createdAt", Asc: false}, {Field: "id", Asc: true}},
+		Values: []interface{}{time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC), int64(10)},
+	}
+
+	encoded, err := codec.Encode(cursor)
+	expect.Nil(err)
+
+	decoded, err := codec.Decode(encoded)
+	expect.Nil(err)
+	expect.True(reflect.DeepEqual(cursor, decoded), "got: %v, want: %v", decoded, cursor)
+
+	_, err = CursorCodec{Secret: []byte("other")}.Decode(encoded)
+	expect.True(errors.Is(err, ErrInvalidCursor), "should reject cursors signed with another secret")
+
+	payload, signature, _ := strings.Cut(encoded, ".")
+	_, err = codec.Decode(payload[1:] + "." + signature)
+	expect.True(errors.Is(err, ErrInvalidCursor), "should reject tampered cursors")
+
+	_, err = codec.Decode("abc")
+	expect.True(errors.Is(err, ErrInvalidCursor))
+
+	_, err = codec.Encode(Cursor{Orders: []Order{{Field: "a"}}, Values: []interface{}{[]int{1}}})
+	expect.True(errors.Is(err, ErrInvalidCursor), "should reject unsupported value types")
+}
+
+func TestCursorCodecWithoutSecret(t *testing.T) {
+	expect := assert.New(t)
+	cursor := Cursor{Orders: []Order{{Field: "id", Asc: true}}, Values: []interface{}{int64(1)}}
+
+	_, err := CursorCodec{}.Encode(cursor)
+	expect.True(errors.Is(err, ErrMissingSecret))
+
+	encoded, err := CursorCodec{Secret: []byte("secret")}.Encode(cursor)
+	expect.Nil(err)
+
+	_, err = CursorCodec{}.Decode(encoded)
+	expect.True(errors.Is(err, ErrMissingSecret), "should not verify signatures with an empty key")
+
+	_, err = parse(url.Values{"cursor": {encoded}}, Options{Cursor: &CursorCodec{}})
+	expect.True(errors.Is(err, ErrMissingSecret))
+}
+
+func TestNextCursor(t *testing.T) {
+	createdAt := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	row := cursorTestRow{
+		ID:        7,
+		Price:     1.5,
+		Total:     "10.50",
+		CreatedAt: createdAt,
+		Customer:  &cursorTestCustomer{Name: "john"},
+		Secret:    "s",
+	}
+
+	type args struct {
+		row    interface{}
+		orders []Order
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []interface{}
+		wantErr error
+	}{
+		{
+			name: "should read struct fields by json name",
+			args: args{row: row, orders: []Order{{Field: "price"}, {Field: "total"}, {Field: "createdAt"}, {Field: "id"}}},
+			want: []interface{}{float64(1.5), Decimal("10.50"), createdAt, int64(7)},
+		},
+		{
+			name: "should read nested fields",
+			args: args{row: &row, orders: []Order{{Field: "customer.name"}, {Field: "id"}}},
+			want: []interface{}{"john", int64(7)},
+		},
+		{
+			name: "should read map keys",
+			args: args{row: map[string]interface{}{"id": 3, "name": "a"}, orders: []Order{{Field: "name"}, {Field: "id"}}},
+			want: []interface{}{"a", int64(3)},
+		},
+		{
+			name:    "should return error when row has no field",
+			args:    args{row: row, orders: []Order{{Field: "Secret"}}},
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextCursor(tt.args.row, tt.args.orders)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.False(t, got.Backward)
+			assert.True(t, reflect.DeepEqual(tt.want, got.Values), "got: %v, want: %v", got.Values, tt.want)
+		})
+	}
+}
+
+func TestPrevCursor(t *testing.T) {
+	expect := assert.New(t)
+	orders := []Order{{Field: "name", Asc: true}, {Field: "id", Asc: false}}
+
+	cursor, err := PrevCursor(map[string]interface{}{"id": 3, "name": "a"}, orders)
+	expect.Nil(err)
+	expect.True(cursor.Backward)
+	expect.True(cursor.Matches(orders))
+	expect.False(cursor.Matches(orders[:1]))
+	expect.Equal([]Order{{Field: "name", Asc: false}, {Field: "id", Asc: true}}, cursor.FetchOrders())
+}
+
+func TestWithTiebreaker(t *testing.T) {
+	expect := assert.New(t)
+	tiebreaker := Order{Field: "id", Asc: true}
+
+	expect.Equal([]Order{{Field: "price"}, tiebreaker}, WithTiebreaker([]Order{{Field: "price"}}, tiebreaker))
+	expect.Equal([]Order{{Field: "id"}, {Field: "price"}}, WithTiebreaker([]Order{{Field: "id"}, {Field: "price"}}, tiebreaker))
+	expect.Equal([]Order{tiebreaker}, WithTiebreaker([]Order{}, tiebreaker))
+}
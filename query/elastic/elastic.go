@@ -0,0 +1,297 @@
+// Package elastic generates Elasticsearch/OpenSearch search request bodies from parsed queries.
+package elastic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+)
+
+var (
+	ErrUnknownField        = errors.New("field has no index field")
+	ErrUnsupportedOperator = errors.New("operator is not supported")
+)
+
+var rangeOperators = map[query.FilterOperator]string{
+	query.FilterOperatorLessThan:           "lt",
+	query.FilterOperatorLessThanOrEqual:    "lte",
+	query.FilterOperatorGreaterThan:        "gt",
+	query.FilterOperatorGretherThanOrEqual: "gte",
+}
+
+var wildcardReplacer = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`)
+
+// JSON object of the query DSL
+type Object = map[string]interface{}
+
+// Generates search request bodies from parsed queries. Only fields present on Fields
+// are ever written to the request.
+type Translator struct {
+	SearchFields []string          // index fields the search is matched against, boosts are allowed, eg: "name^2"
+	Fields       map[string]string // maps the field names sent by clients to index fields, eg: "customerName" -> "customer.name.keyword"
+	SourceFields map[string]string // maps the field names to _source paths for projections, eg: "customerName" -> "customer.name", falls back to Fields
+}
+
+// Generates the search request body: the search becomes a "multi_match" over SearchFields,
+// the filters become clauses of a bool query in filter context, the orders become "sort"
+// and the pagination becomes "from" and "size"
+func (t Translator) Request(search string, filters []query.Filter, orders []query.Order, pagination query.Paginable) (Object, error) {
+	q, err := t.Query(search, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.request(q, orders, pagination)
+}
+
+// Same as Request, but with a parsed query, using its filters expression when it has one
+// and restricting "_source" to its fields
+func (t Translator) RequestQuery(q query.Query) (Object, error) {
+	var queryObject Object
+	var err error
+
+	if q.Expr == nil {
+		queryObject, err = t.Query(q.Search, q.Filters)
+	} else {
+		queryObject, err = t.QueryExpr(q.Search, *q.Expr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := t.request(queryObject, q.Orders, q.Pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(q.Fields) > 0 {
+		source, err := t.Source(q.Fields)
+		if err != nil {
+			return nil, err
+		}
+		body["_source"] = source
+	}
+
+	return body, nil
+}
+
+func (t Translator) request(q Object, orders []query.Order, pagination query.Paginable) (Object, error) {
+	sort, err := t.Sort(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	body := Object{
+		"query": q,
+		"from":  pagination.Offset,
+		"size":  pagination.Limit,
+	}
+
+	if len(sort) > 0 {
+		body["sort"] = sort
+	}
+
+	return body, nil
+}
+
+// Generates the "query" object of the request, matching all documents when there is no search nor filters
+func (t Translator) Query(search string, filters []query.Filter) (Object, error) {
+	boolQuery := Object{}
+
+	if search != "" {
+		boolQuery["must"] = []interface{}{
+			Object{"multi_match": Object{"query": search, "fields": t.SearchFields}},
+		}
+	}
+
+	filter := []interface{}{}
+	mustNot := []interface{}{}
+
+	for _, f := range filters {
+		field, err := t.field(f.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		clause, negated, err := clause(field, f)
+		if err != nil {
+			return nil, err
+		}
+
+		if negated {
+			mustNot = append(mustNot, clause)
+			continue
+		}
+
+		filter = append(filter, clause)
+	}
+
+	if len(filter) > 0 {
+		boolQuery["filter"] = filter
+	}
+
+	if len(mustNot) > 0 {
+		boolQuery["must_not"] = mustNot
+	}
+
+	if len(boolQuery) == 0 {
+		return Object{"match_all": Object{}}, nil
+	}
+
+	return Object{"bool": boolQuery}, nil
+}
+
+// Same as Query, but with the filters of an expression: AND becomes a bool "filter",
+// OR a bool "should" and NOT a bool "must_not"
+func (t Translator) QueryExpr(search string, expr query.FilterExpr) (Object, error) {
+	if err := expr.Validate(); err != nil {
+		return nil, err
+	}
+
+	boolQuery := Object{}
+
+	if search != "" {
+		boolQuery["must"] = []interface{}{
+			Object{"multi_match": Object{"query": search, "fields": t.SearchFields}},
+		}
+	}
+
+	filter, err := t.expr(expr)
+	if err != nil {
+		return nil, err
+	}
+	boolQuery["filter"] = []interface{}{filter}
+
+	return Object{"bool": boolQuery}, nil
+}
+
+func (t Translator) expr(e query.FilterExpr) (Object, error) {
+	if e.Kind == query.FilterExprLeaf {
+		field, err := t.field(e.Filter.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		c, negated, err := clause(field, *e.Filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if negated {
+			return Object{"bool": Object{"must_not": []interface{}{c}}}, nil
+		}
+		return c, nil
+	}
+
+	children := []interface{}{}
+	for _, child := range e.Children {
+		c, err := t.expr(child)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, c)
+	}
+
+	switch e.Kind {
+	case query.FilterExprNot:
+		return Object{"bool": Object{"must_not": children}}, nil
+	case query.FilterExprOr:
+		return Object{"bool": Object{"should": children, "minimum_should_match": 1}}, nil
+	}
+
+	return Object{"bool": Object{"filter": children}}, nil
+}
+
+// The clause the filter translates to, which must be placed in a "must_not" when negated is true
+func clause(field string, f query.Filter) (c Object, negated bool, err error) {
+	if op, ok := rangeOperators[f.Operation]; ok {
+		return Object{"range": Object{field: Object{op: f.TypedValue()}}}, false, nil
+	}
+
+	switch f.Operation {
+	case query.FilterOperatorEqual:
+		return Object{"term": Object{field: f.TypedValue()}}, false, nil
+	case query.FilterOperatorNotEqual:
+		return Object{"term": Object{field: f.TypedValue()}}, true, nil
+	case query.FilterOperatorIn:
+		return Object{"terms": Object{field: f.TypedValues()}}, false, nil
+	case query.FilterOperatorNotIn:
+		return Object{"terms": Object{field: f.TypedValues()}}, true, nil
+	case query.FilterOperatorBetween:
+		values := f.TypedValues()
+		if len(values) != 2 {
+			return nil, false, fmt.Errorf("%w: %q takes 2", query.ErrValueCount, f.Operation)
+		}
+		return Object{"range": Object{field: Object{"gte": values[0], "lte": values[1]}}}, false, nil
+	case query.FilterOperatorIsNull:
+		return Object{"exists": Object{"field": field}}, true, nil
+	case query.FilterOperatorNotNull:
+		return Object{"exists": Object{"field": field}}, false, nil
+	case query.FilterOperatorStartsWith:
+		return Object{"prefix": Object{field: f.Value}}, false, nil
+	case query.FilterOperatorEndsWith:
+		return Object{"wildcard": Object{field: "*" + wildcardReplacer.Replace(f.Value)}}, false, nil
+	case query.FilterOperatorContains:
+		return Object{"wildcard": Object{field: "*" + wildcardReplacer.Replace(f.Value) + "*"}}, false, nil
+	case query.FilterOperatorEqualFold:
+		return Object{"term": Object{field: Object{"value": f.Value, "case_insensitive": true}}}, false, nil
+	case query.FilterOperatorContainsFold:
+		return Object{"wildcard": Object{field: Object{"value": "*" + wildcardReplacer.Replace(f.Value) + "*", "case_insensitive": true}}}, false, nil
+	case query.FilterOperatorRegex:
+		return Object{"regexp": Object{field: f.Value}}, false, nil
+	}
+
+	return nil, false, fmt.Errorf("%w: %q", ErrUnsupportedOperator, f.Operation)
+}
+
+// Generates the "sort" array of the request, eg: [{"price": {"order": "asc"}}]
+func (t Translator) Sort(orders []query.Order) ([]interface{}, error) {
+	sort := []interface{}{}
+
+	for _, o := range orders {
+		field, err := t.field(o.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		direction := "desc"
+		if o.Asc {
+			direction = "asc"
+		}
+
+		sort = append(sort, Object{field: Object{"order": direction}})
+	}
+
+	return sort, nil
+}
+
+// Maps the requested fields to the "_source" paths of the request, eg: ["id", "customer.name"]
+func (t Translator) Source(fields []string) ([]string, error) {
+	source := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		path, ok := t.SourceFields[f]
+		if !ok {
+			var err error
+			path, err = t.field(f)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		source = append(source, path)
+	}
+
+	return source, nil
+}
+
+func (t Translator) field(field string) (string, error) {
+	f, ok := t.Fields[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownField, field)
+	}
+	return f, nil
+}
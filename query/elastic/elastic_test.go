@@ -0,0 +1,183 @@
+package elastic
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var testTranslator = Translator{
+	SearchFields: []string{"name^2", "description"},
+	Fields: map[string]string{
+		"price":     "price",
+		"name":      "name.keyword",
+		"tags":      "tags",
+		"createdAt": "created_at",
+	},
+	SourceFields: map[string]string{
+		"name": "name",
+	},
+}
+
+func TestTranslatorRequest(t *testing.T) {
+	type args struct {
+		search     string
+		filters    []query.Filter
+		orders     []query.Order
+		pagination query.Paginable
+	}
+	tests := []struct {
+		name   string
+		golden string
+		args   args
+	}{
+		{
+			name:   "should match all documents when there is no search nor filters",
+			golden: "match_all",
+			args: args{
+				pagination: query.Paginable{Limit: 10},
+			},
+		},
+		{
+			name:   "should generate multi match from search",
+			golden: "search",
+			args: args{
+				search:     "red shoes",
+				orders:     []query.Order{{Field: "createdAt", Asc: false}},
+				pagination: query.Paginable{Limit: 20, Offset: 40},
+			},
+		},
+		{
+			name:   "should generate filter clauses",
+			golden: "filters",
+			args: args{
+				search: "shoes",
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorGretherThanOrEqual, Value: "10", Typed: int64(10)},
+					{Field: "price", Operation: query.FilterOperatorLessThan, Value: "20", Typed: int64(20)},
+					{Field: "name", Operation: query.FilterOperatorEqual, Value: "boot"},
+					{Field: "name", Operation: query.FilterOperatorNotEqual, Value: "sandal"},
+					{Field: "tags", Operation: query.FilterOperatorIn, Value: "a;b"},
+					{Field: "name", Operation: query.FilterOperatorStartsWith, Value: "bo"},
+					{Field: "name", Operation: query.FilterOperatorEndsWith, Value: "t*"},
+					{Field: "name", Operation: query.FilterOperatorContains, Value: "o?"},
+				},
+				orders:     []query.Order{{Field: "price", Asc: true}, {Field: "name", Asc: false}},
+				pagination: query.Paginable{Limit: 10},
+			},
+		},
+		{
+			name:   "should generate range, exclusion, null and case insensitive clauses",
+			golden: "operators",
+			args: args{
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorBetween, Value: "1;2", Typed: []int64{1, 2}},
+					{Field: "tags", Operation: query.FilterOperatorNotIn, Value: "a;b"},
+					{Field: "createdAt", Operation: query.FilterOperatorIsNull},
+					{Field: "price", Operation: query.FilterOperatorNotNull},
+					{Field: "name", Operation: query.FilterOperatorEqualFold, Value: "Boot"},
+					{Field: "name", Operation: query.FilterOperatorContainsFold, Value: "oo*"},
+					{Field: "name", Operation: query.FilterOperatorRegex, Value: "b.*t"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := testTranslator.Request(tt.args.search, tt.args.filters, tt.args.orders, tt.args.pagination)
+			assert.Nil(t, err)
+
+			got, err := json.MarshalIndent(body, "", "  ")
+			assert.Nil(t, err)
+
+			path := filepath.Join("testdata", tt.golden+".golden.json")
+			if *update {
+				assert.Nil(t, os.WriteFile(path, append(got, '\n'), 0644))
+			}
+
+			want, err := os.ReadFile(path)
+			assert.Nil(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
+
+func TestTranslatorRequestQuery(t *testing.T) {
+	expr := query.And(
+		query.Or(
+			query.Leaf(query.Filter{Field: "name", Operation: query.FilterOperatorEqual, Value: "boot"}),
+			query.Leaf(query.Filter{Field: "price", Operation: query.FilterOperatorLessThan, Value: "5", Typed: int64(5)}),
+		),
+		query.Not(query.Leaf(query.Filter{Field: "tags", Operation: query.FilterOperatorIn, Value: "x;y"})),
+		query.Leaf(query.Filter{Field: "name", Operation: query.FilterOperatorNotEqual, Value: "sandal"}),
+	)
+
+	body, err := testTranslator.RequestQuery(query.Query{
+		Search:     "shoes",
+		Expr:       &expr,
+		Orders:     []query.Order{{Field: "price", Asc: true}},
+		Pagination: query.Paginable{Limit: 10},
+	})
+	assert.Nil(t, err)
+
+	got, err := json.MarshalIndent(body, "", "  ")
+	assert.Nil(t, err)
+
+	path := filepath.Join("testdata", "expr.golden.json")
+	if *update {
+		assert.Nil(t, os.WriteFile(path, append(got, '\n'), 0644))
+	}
+
+	want, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+func TestTranslatorSource(t *testing.T) {
+	expect := assert.New(t)
+
+	body, err := testTranslator.RequestQuery(query.Query{
+		Fields:     []string{"name", "price"},
+		Pagination: query.Paginable{Limit: 10},
+	})
+	expect.Nil(err)
+	expect.Equal([]string{"name", "price"}, body["_source"])
+
+	_, err = testTranslator.Source([]string{"password"})
+	expect.True(errors.Is(err, ErrUnknownField))
+}
+
+func TestTranslatorRequestErrors(t *testing.T) {
+	expect := assert.New(t)
+
+	_, err := testTranslator.Request("", []query.Filter{{Field: "password", Operation: query.FilterOperatorEqual, Value: "1"}}, nil, query.Paginable{})
+	expect.True(errors.Is(err, ErrUnknownField))
+
+	_, err = testTranslator.Request("", nil, []query.Order{{Field: "password", Asc: true}}, query.Paginable{})
+	expect.True(errors.Is(err, ErrUnknownField))
+
+	_, err = testTranslator.Request("", []query.Filter{{Field: "price", Operation: query.FilterOperator("like"), Value: "1"}}, nil, query.Paginable{})
+	expect.True(errors.Is(err, ErrUnsupportedOperator))
+
+	_, err = testTranslator.RequestQuery(query.Query{Expr: &query.FilterExpr{Kind: query.FilterExprLeaf}})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.QueryExpr("", query.FilterExpr{Kind: query.FilterExprNot})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.QueryExpr("", query.And())
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.QueryExpr("", query.Not(query.Or()))
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+}
@@ -0,0 +1,58 @@
+package query
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Encodes the query in the grammar Parse reads, so next and previous links and calls to other
+// services need no string concatenation, eg: "filters=price[gt]10&limit=10&offset=0&order=price:asc".
+// The pagination is always encoded as limit and offset. The cursor is not encoded, as it must be
+// signed, see EncodeWithCursor.
+func Encode(q Query) url.Values {
+	values := url.Values{}
+
+	values.Set(string(QueryKeyLimit), strconv.Itoa(q.Pagination.Limit))
+	if q.Pagination.Cursor == nil {
+		values.Set(string(QueryKeyOffset), strconv.Itoa(q.Pagination.Offset))
+	}
+
+	if q.Expr != nil {
+		values.Set(string(QueryKeyFilters), EncodeFilterExpr(*q.Expr))
+	} else if len(q.Filters) > 0 {
+		values.Set(string(QueryKeyFilters), EncodeFilters(q.Filters))
+	}
+
+	if len(q.Orders) > 0 {
+		values.Set(string(QueryKeyOrder), EncodeOrders(q.Orders))
+	}
+
+	if len(q.Fields) > 0 {
+		values.Set(string(QueryKeyFields), EncodeFields(q.Fields))
+	}
+
+	if len(q.Includes) > 0 {
+		values.Set(string(QueryKeyInclude), EncodeIncludes(q.Includes))
+	}
+
+	if q.Search != "" {
+		values.Set(string(QueryKeySearch), q.Search)
+	}
+
+	return values
+}
+
+// Same as Encode, but also encodes the pagination cursor signed by the codec
+func EncodeWithCursor(q Query, codec CursorCodec) (url.Values, error) {
+	values := Encode(q)
+
+	if q.Pagination.Cursor != nil {
+		cursor, err := codec.Encode(*q.Pagination.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		values.Set(string(QueryKeyCursor), cursor)
+	}
+
+	return values, nil
+}
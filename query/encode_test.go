@@ -0,0 +1,162 @@
+package query
+
+import (
+	"math/rand"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	q := Query{
+		Pagination: Paginable{Limit: 20, Offset: 40},
+		Filters: []Filter{
+			{Field: "name", Operation: FilterOperatorEqual, Value: "Smith, John"},
+			{Field: "tags", Operation: FilterOperatorIn, Value: JoinValues([]string{"a;b", "c"})},
+			{Field: "deletedAt", Operation: FilterOperatorIsNull},
+		},
+		Orders: []Order{{Field: "price", Asc: true}},
+		Search: "abc",
+	}
+
+	assert.Equal(t, url.Values{
+		"limit":   {"20"},
+		"offset":  {"40"},
+		"filters": {`name[eq]Smith\, John,tags[in]a\;b;c,deletedAt[isNull]`},
+		"order":   {"price:asc"},
+		"search":  {"abc"},
+	}, Encode(q))
+}
+
+func TestEncodeWithExpr(t *testing.T) {
+	expr := And(
+		Or(Leaf(Filter{Field: "status", Operation: FilterOperatorEqual, Value: "open"}), Leaf(Filter{Field: "assignee", Operation: FilterOperatorEqual, Value: "me"})),
+		Not(Leaf(Filter{Field: "deleted", Operation: FilterOperatorEqual, Value: "true"})),
+	)
+	q := Query{Pagination: Paginable{Limit: 10}, Filters: []Filter{}, Expr: &expr, Orders: []Order{}}
+
+	got, err := parse(Encode(q), Options{Grouping: true})
+	assert.Nil(t, err)
+	assert.True(t, reflect.DeepEqual(q, got), "got: %v, want: %v", got, q)
+}
+
+func TestEncodeWithCursor(t *testing.T) {
+	codec := CursorCodec{Secret: []byte("secret")}
+	q := Query{
+		Pagination: Paginable{Limit: 10, Cursor: &Cursor{Orders: []Order{{Field: "id", Asc: true}}, Values: []interface{}{int64(7)}}},
+		Filters:    []Filter{},
+		Orders:     []Order{{Field: "id", Asc: true}},
+	}
+
+	values, err := EncodeWithCursor(q, codec)
+	assert.Nil(t, err)
+	assert.NotContains(t, values, "offset")
+
+	got, err := parse(values, Options{Cursor: &codec})
+	assert.Nil(t, err)
+	assert.True(t, reflect.DeepEqual(q, got), "got: %v, want: %v", got, q)
+}
+
+// Seed of the round trip queries, overridden by the QUERY_ROUNDTRIP_SEED env var to replay or explore others
+const roundTripSeed int64 = 20240611
+
+// Parse(Encode(q)) == q for queries made of every character the grammar treats as special
+func TestEncodeParseRoundTrip(t *testing.T) {
+	seed := roundTripSeed
+	if env := os.Getenv("QUERY_ROUNDTRIP_SEED"); env != "" {
+		parsed, err := strconv.ParseInt(env, 10, 64)
+		if !assert.Nil(t, err) {
+			return
+		}
+		seed = parsed
+	}
+	r := rand.New(rand.NewSource(seed))
+	opts := Options{Strict: true, Pagination: PaginationOptions{AllowCountOnly: true}}
+
+	for i := 0; i < 1000; i++ {
+		q := randomQuery(r)
+
+		// encoded as a URL query too, to go through percent encoding
+		values, err := url.ParseQuery(Encode(q).Encode())
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		got, err := parse(values, opts)
+		if !assert.Nil(t, err, "seed: %d, query: %v", seed, values) {
+			return
+		}
+		if !assert.True(t, reflect.DeepEqual(q, got), "seed: %d, got: %v, want: %v", seed, got, q) {
+			return
+		}
+	}
+}
+
+var randomOperators = []FilterOperator{
+	FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorGreaterThan, FilterOperatorContains,
+	FilterOperatorIn, FilterOperatorNotIn, FilterOperatorBetween, FilterOperatorIsNull, FilterOperatorEqualFold,
+}
+
+func randomQuery(r *rand.Rand) Query {
+	q := Query{
+		Pagination: Paginable{Limit: 1 + r.Intn(100), Offset: r.Intn(1000)},
+		Filters:    []Filter{},
+		Orders:     []Order{},
+		Search:     strings.Trim(randomString(r), " "),
+	}
+
+	if r.Intn(10) == 0 {
+		q.Pagination = Paginable{CountOnly: true, Offset: q.Pagination.Offset}
+	}
+
+	for i := r.Intn(4); i > 0; i-- {
+		op := randomOperators[r.Intn(len(randomOperators))]
+		f := Filter{Field: "f" + randomString(r), Operation: op}
+
+		switch op.Arity() {
+		case ArityOne:
+			f.Value = randomString(r)
+		case ArityTwo:
+			f.Value = JoinValues([]string{randomString(r), randomString(r)})
+		case ArityMany:
+			f.Value = JoinValues([]string{randomString(r), randomString(r), randomString(r)})
+		}
+
+		q.Filters = append(q.Filters, f)
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		// prefixed by i so paths are never repeated
+		q.Fields = append(q.Fields, "a"+strconv.Itoa(i)+randomString(r)+FieldPathSeparator+"b"+randomString(r))
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		relations := []string{"r" + strconv.Itoa(i) + randomString(r)}
+		if r.Intn(2) == 0 {
+			relations = append(relations, "n"+randomString(r))
+		}
+		q.Includes.add(relations)
+	}
+
+	for i := r.Intn(3); i > 0; i-- {
+		q.Orders = append(q.Orders, Order{Field: "o" + randomString(r), Asc: r.Intn(2) == 0})
+	}
+
+	return q
+}
+
+func randomString(r *rand.Rand) string {
+	const alphabet = `ab ,;:[]()|!"\%&=+é`
+	runes := []rune(alphabet)
+
+	s := make([]rune, r.Intn(8))
+	for i := range s {
+		s[i] = runes[r.Intn(len(runes))]
+	}
+	return string(s)
+}
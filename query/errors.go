@@ -0,0 +1,73 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMissingBracket   = errors.New("missing operator bracket")
+	ErrUnknownOperator  = errors.New("unknown operator")
+	ErrInvalidField     = errors.New("field is empty or has no letters")
+	ErrEmptyValue       = errors.New("empty value")
+	ErrUnexpectedValue  = errors.New("operator takes no value")
+	ErrValueCount       = errors.New("wrong amount of values for operator")
+	ErrMissingDirection = errors.New("missing order direction")
+	ErrInvalidDirection = errors.New("order direction must be asc or desc")
+)
+
+// Describes a malformed segment of a query value, eg: "price[gtt]10" on "filters=price[gtt]10,name[eq]a"
+type SegmentError struct {
+	Key      QueryKey // query key the segment was read from
+	Segment  string   // the raw segment
+	Position int      // byte offset of the segment on the query value
+	Err      error    // the reason the segment is malformed
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("%s: %q at position %d: %v", e.Key, e.Segment, e.Position, e.Err)
+}
+
+func (e *SegmentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *SegmentError) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key      QueryKey `json:"key"`
+		Segment  string   `json:"segment"`
+		Position int      `json:"position"`
+		Reason   string   `json:"reason"`
+	}{e.Key, e.Segment, e.Position, e.Err.Error()})
+}
+
+// Aggregates every malformed segment found while parsing a query
+type ParseErrors []*SegmentError
+
+func (e ParseErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Reports whether any of the segment errors matches target
+func (e ParseErrors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns nil when there are no errors, so callers never get a non nil error holding an empty slice
+func (e ParseErrors) orNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
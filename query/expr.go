@@ -0,0 +1,359 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrUnbalancedParens = errors.New("unbalanced parentheses")
+	ErrEmptyExpr        = errors.New("empty expression")
+	ErrExprTooDeep      = errors.New("expression is too deep")
+	ErrExprTooLarge     = errors.New("expression has too many nodes")
+	ErrInvalidExpr      = errors.New("invalid expression")
+)
+
+// Characters of the filters expression grammar, eg: "(status[eq]open|assignee[eq]me),!deleted[eq]true".
+// QueryParamSeparatorMap joins filters with AND and binds tighter than QueryParamSeparatorOr.
+const (
+	QueryParamSeparatorOr         QueryParamSeparator = "|"
+	QueryParamSeparatorNot        QueryParamSeparator = "!"
+	QueryParamSeparatorGroupStart QueryParamSeparator = "("
+	QueryParamSeparatorGroupEnd   QueryParamSeparator = ")"
+)
+
+type FilterExprKind string
+
+const (
+	FilterExprAnd  FilterExprKind = "and"
+	FilterExprOr   FilterExprKind = "or"
+	FilterExprNot  FilterExprKind = "not"
+	FilterExprLeaf FilterExprKind = "leaf"
+)
+
+// Boolean expression of filters
+type FilterExpr struct {
+	Kind     FilterExprKind `json:"kind"`
+	Children []FilterExpr   `json:"children,omitempty"` // operands of and/or, or the single operand of not
+	Filter   *Filter        `json:"filter,omitempty"`   // set on leaves only
+}
+
+func And(children ...FilterExpr) FilterExpr {
+	return FilterExpr{Kind: FilterExprAnd, Children: children}
+}
+
+func Or(children ...FilterExpr) FilterExpr {
+	return FilterExpr{Kind: FilterExprOr, Children: children}
+}
+
+func Not(child FilterExpr) FilterExpr {
+	return FilterExpr{Kind: FilterExprNot, Children: []FilterExpr{child}}
+}
+
+func Leaf(filter Filter) FilterExpr {
+	return FilterExpr{Kind: FilterExprLeaf, Filter: &filter}
+}
+
+// Checks the shape of the expression, as ones decoded from JSON may lack a leaf filter or a NOT operand.
+// AND and OR without operands are rejected too, as backends disagree on what they match.
+// Translators call it before walking the expression.
+func (e FilterExpr) Validate() error {
+	switch e.Kind {
+	case FilterExprLeaf:
+		if e.Filter == nil || len(e.Children) > 0 {
+			return fmt.Errorf("%w: leaf must have a filter and no children", ErrInvalidExpr)
+		}
+		return nil
+	case FilterExprNot:
+		if len(e.Children) != 1 {
+			return fmt.Errorf("%w: not must have exactly one child", ErrInvalidExpr)
+		}
+	case FilterExprAnd, FilterExprOr:
+		if len(e.Children) == 0 {
+			return fmt.Errorf("%w: %s must have children", ErrInvalidExpr, e.Kind)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", ErrInvalidExpr, e.Kind)
+	}
+
+	if e.Filter != nil {
+		return fmt.Errorf("%w: %s must not have a filter", ErrInvalidExpr, e.Kind)
+	}
+
+	for _, c := range e.Children {
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Every filter of the expression, from left to right
+func (e FilterExpr) Filters() []Filter {
+	if e.Kind == FilterExprLeaf {
+		return []Filter{*e.Filter}
+	}
+
+	filters := []Filter{}
+	for _, c := range e.Children {
+		filters = append(filters, c.Filters()...)
+	}
+	return filters
+}
+
+// Amount of filters and operators of the expression, as bounded by ExprLimits.MaxNodes
+func (e FilterExpr) size() int {
+	n := 1
+	for _, c := range e.Children {
+		n += c.size()
+	}
+	return n
+}
+
+// Returns whether the expression only ANDs filters, so it is fully described by Filters
+func (e FilterExpr) IsConjunction() bool {
+	switch e.Kind {
+	case FilterExprLeaf:
+		return true
+	case FilterExprAnd:
+		for _, c := range e.Children {
+			if !c.IsConjunction() {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Bounds filters expressions to prevent abuse
+type ExprLimits struct {
+	MaxDepth int // maximum nesting of groups and negations, defaults to 5
+	MaxNodes int // maximum amount of filters and operators, defaults to 50
+}
+
+func (l ExprLimits) withDefaults() ExprLimits {
+	if l.MaxDepth == 0 {
+		l.MaxDepth = 5
+	}
+	if l.MaxNodes == 0 {
+		l.MaxNodes = 50
+	}
+	return l
+}
+
+// Parses a filters value with groups, OR and NOT into an expression,
+// eg: "(status[eq]open|assignee[eq]me),!deleted[eq]true".
+// Unlike the flat grammar, malformed filters are always reported, as dropping them would change the meaning of the expression.
+func ParseFilterExpr(value string, limits ExprLimits) (*FilterExpr, error) {
+	expr, errs := parseFilterExpr(value, limits, nil)
+	return expr, errs.orNil()
+}
+
+// Same as ParseFilterExpr, reading the value of the filters key. Returns nil when the query has no filters.
+// Repeated filters keys are ANDed.
+func GetFilterExprFromQuery(c *fiber.Ctx, limits ExprLimits) (*FilterExpr, error) {
+	values := queryArgsToValues(c)[string(QueryKeyFilters)]
+	_, expr, errs := parseFilterValues(values, Options{Grouping: true, ExprLimits: limits})
+	return expr, errs.orNil()
+}
+
+type exprParser struct {
+	value  string
+	pos    int
+	limits ExprLimits
+	schema *Schema
+	nodes  int
+	errs   ParseErrors
+}
+
+func parseFilterExpr(value string, limits ExprLimits, schema *Schema) (*FilterExpr, ParseErrors) {
+	if value == "" {
+		return nil, ParseErrors{}
+	}
+
+	p := &exprParser{value: value, limits: limits.withDefaults(), schema: schema, errs: ParseErrors{}}
+
+	expr, err := p.or(0)
+	if err == nil && p.pos < len(p.value) {
+		err = p.error(p.pos, p.value[p.pos:], ErrUnbalancedParens)
+	}
+
+	if err != nil {
+		return nil, append(p.errs, err)
+	}
+
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+
+	return &expr, p.errs
+}
+
+func (p *exprParser) error(position int, text string, err error) *SegmentError {
+	return segment{text: text, position: position}.error(QueryKeyFilters, err)
+}
+
+func (p *exprParser) peek(sep QueryParamSeparator) bool {
+	return strings.HasPrefix(p.value[p.pos:], string(sep))
+}
+
+func (p *exprParser) node(start int) *SegmentError {
+	p.nodes++
+	if p.nodes > p.limits.MaxNodes {
+		return p.error(start, p.value[start:], ErrExprTooLarge)
+	}
+	return nil
+}
+
+func (p *exprParser) or(depth int) (FilterExpr, *SegmentError) {
+	start := p.pos
+	return p.list(depth, start, QueryParamSeparatorOr, Or, p.and)
+}
+
+func (p *exprParser) and(depth int) (FilterExpr, *SegmentError) {
+	start := p.pos
+	return p.list(depth, start, QueryParamSeparatorMap, And, p.unary)
+}
+
+// Parses operands separated by sep, combining them with combine when there is more than one
+func (p *exprParser) list(depth, start int, sep QueryParamSeparator, combine func(...FilterExpr) FilterExpr, operand func(int) (FilterExpr, *SegmentError)) (FilterExpr, *SegmentError) {
+	children := []FilterExpr{}
+
+	for {
+		child, err := operand(depth)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, child)
+
+		if !p.peek(sep) {
+			break
+		}
+		p.pos += len(sep)
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	if err := p.node(start); err != nil {
+		return FilterExpr{}, err
+	}
+
+	return combine(children...), nil
+}
+
+func (p *exprParser) unary(depth int) (FilterExpr, *SegmentError) {
+	start := p.pos
+
+	switch {
+	case p.peek(QueryParamSeparatorNot):
+		if depth+1 > p.limits.MaxDepth {
+			return FilterExpr{}, p.error(start, p.value[start:], ErrExprTooDeep)
+		}
+		if err := p.node(start); err != nil {
+			return FilterExpr{}, err
+		}
+
+		p.pos += len(QueryParamSeparatorNot)
+		child, err := p.unary(depth + 1)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		return Not(child), nil
+	case p.peek(QueryParamSeparatorGroupStart):
+		if depth+1 > p.limits.MaxDepth {
+			return FilterExpr{}, p.error(start, p.value[start:], ErrExprTooDeep)
+		}
+
+		p.pos += len(QueryParamSeparatorGroupStart)
+		child, err := p.or(depth + 1)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+
+		if !p.peek(QueryParamSeparatorGroupEnd) {
+			return FilterExpr{}, p.error(start, p.value[start:p.pos], ErrUnbalancedParens)
+		}
+		p.pos += len(QueryParamSeparatorGroupEnd)
+		return child, nil
+	}
+
+	return p.leaf()
+}
+
+// Parses a filter up to the next unescaped and unquoted "," "|" or ")"
+func (p *exprParser) leaf() (FilterExpr, *SegmentError) {
+	start := p.pos
+	end := len(p.value)
+
+	for _, sep := range []QueryParamSeparator{QueryParamSeparatorMap, QueryParamSeparatorOr, QueryParamSeparatorGroupEnd} {
+		if i := indexUnescaped(p.value[start:], sep); i != -1 && start+i < end {
+			end = start + i
+		}
+	}
+
+	p.pos = end
+	text := p.value[start:end]
+
+	if text == "" {
+		return FilterExpr{}, p.error(start, text, ErrEmptyExpr)
+	}
+
+	if err := p.node(start); err != nil {
+		return FilterExpr{}, err
+	}
+
+	f, err := getFilter(text, p.schema != nil)
+	if err == nil && p.schema != nil {
+		f, err = p.schema.CoerceFilter(f)
+	}
+
+	if err != nil {
+		// keeps parsing to report every malformed filter
+		p.errs = append(p.errs, p.error(start, text, err))
+		return Leaf(Filter{}), nil
+	}
+
+	return Leaf(f), nil
+}
+
+// Encodes the expression the way ParseFilterExpr parses it
+func EncodeFilterExpr(e FilterExpr) string {
+	switch e.Kind {
+	case FilterExprLeaf:
+		return EncodeFilter(*e.Filter)
+	case FilterExprNot:
+		return string(QueryParamSeparatorNot) + encodeOperand(e.Children[0], FilterExprNot)
+	case FilterExprAnd, FilterExprOr:
+		sep := QueryParamSeparatorMap
+		if e.Kind == FilterExprOr {
+			sep = QueryParamSeparatorOr
+		}
+
+		operands := make([]string, 0, len(e.Children))
+		for _, c := range e.Children {
+			operands = append(operands, encodeOperand(c, e.Kind))
+		}
+		return strings.Join(operands, string(sep))
+	}
+
+	return ""
+}
+
+// Wraps the operand in a group when it would otherwise bind to its parent differently
+func encodeOperand(e FilterExpr, parent FilterExprKind) string {
+	encoded := EncodeFilterExpr(e)
+
+	needsGroup := (e.Kind == FilterExprOr && parent != FilterExprOr) ||
+		(e.Kind == FilterExprAnd && parent == FilterExprNot)
+
+	if needsGroup {
+		return string(QueryParamSeparatorGroupStart) + encoded + string(QueryParamSeparatorGroupEnd)
+	}
+	return encoded
+}
@@ -0,0 +1,201 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilterExpr(t *testing.T) {
+	status := Leaf(Filter{Field: "status", Operation: FilterOperatorEqual, Value: "open"})
+	assignee := Leaf(Filter{Field: "assignee", Operation: FilterOperatorEqual, Value: "me"})
+	deleted := Leaf(Filter{Field: "deleted", Operation: FilterOperatorEqual, Value: "true"})
+
+	type args struct {
+		value  string
+		limits ExprLimits
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    *FilterExpr
+		wantErr error
+	}{
+		{
+			name: "should return nil when value is empty",
+			args: args{value: ""},
+			want: nil,
+		},
+		{
+			name: "should return leaf when value has a single filter",
+			args: args{value: "status[eq]open"},
+			want: &status,
+		},
+		{
+			name: "should parse comma as and",
+			args: args{value: "status[eq]open,assignee[eq]me"},
+			want: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{status, assignee}},
+		},
+		{
+			name: "should parse pipe as or binding looser than and",
+			args: args{value: "status[eq]open,assignee[eq]me|deleted[eq]true"},
+			want: &FilterExpr{Kind: FilterExprOr, Children: []FilterExpr{
+				And(status, assignee),
+				deleted,
+			}},
+		},
+		{
+			name: "should parse groups and negations",
+			args: args{value: "(status[eq]open|assignee[eq]me),!deleted[eq]true"},
+			want: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{
+				Or(status, assignee),
+				Not(deleted),
+			}},
+		},
+		{
+			name: "should parse negated groups",
+			args: args{value: "!(status[eq]open,assignee[eq]me)"},
+			want: &FilterExpr{Kind: FilterExprNot, Children: []FilterExpr{And(status, assignee)}},
+		},
+		{
+			name: "should keep escaped and quoted grammar characters on values",
+			args: args{value: `\!a[eq]"x|y",b[eq]f\(x\)`},
+			want: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{
+				Leaf(Filter{Field: "!a", Operation: FilterOperatorEqual, Value: "x|y"}),
+				Leaf(Filter{Field: "b", Operation: FilterOperatorEqual, Value: "f(x)"}),
+			}},
+		},
+		{
+			name:    "should return error when group is not closed",
+			args:    args{value: "(status[eq]open|assignee[eq]me"},
+			wantErr: ErrUnbalancedParens,
+		},
+		{
+			name:    "should return error when group is not opened",
+			args:    args{value: "status[eq]open)"},
+			wantErr: ErrUnbalancedParens,
+		},
+		{
+			name:    "should return error when operand is empty",
+			args:    args{value: "status[eq]open|"},
+			wantErr: ErrEmptyExpr,
+		},
+		{
+			name:    "should return error when filter is malformed",
+			args:    args{value: "status[xx]open|assignee[eq]me"},
+			wantErr: ErrUnknownOperator,
+		},
+		{
+			name:    "should return error when expression is too deep",
+			args:    args{value: "((!a[eq]b))", limits: ExprLimits{MaxDepth: 2}},
+			wantErr: ErrExprTooDeep,
+		},
+		{
+			name:    "should return error when expression has too many nodes",
+			args:    args{value: "a[eq]b,c[eq]d|e[eq]f", limits: ExprLimits{MaxNodes: 3}},
+			wantErr: ErrExprTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFilterExpr(tt.args.value, tt.args.limits)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestEncodeFilterExpr(t *testing.T) {
+	expr := And(
+		Or(
+			Leaf(Filter{Field: "status", Operation: FilterOperatorEqual, Value: "open|closed"}),
+			Leaf(Filter{Field: "assignee", Operation: FilterOperatorEqual, Value: "(me)"}),
+		),
+		Not(And(
+			Leaf(Filter{Field: "!deleted", Operation: FilterOperatorEqual, Value: "true"}),
+			Leaf(Filter{Field: "tags", Operation: FilterOperatorIn, Value: JoinValues([]string{"a|b", "c"})}),
+		)),
+	)
+
+	encoded := EncodeFilterExpr(expr)
+	assert.Equal(t, `(status[eq]open\|closed|assignee[eq]\(me\)),!(\!deleted[eq]true,tags[in]a\|b;c)`, encoded)
+
+	got, err := ParseFilterExpr(encoded, ExprLimits{})
+	assert.Nil(t, err)
+	assert.True(t, reflect.DeepEqual(&expr, got), "got: %v, want: %v", got, expr)
+}
+
+func TestFilterExprFilters(t *testing.T) {
+	a := Filter{Field: "a", Operation: FilterOperatorEqual, Value: "1"}
+	b := Filter{Field: "b", Operation: FilterOperatorEqual, Value: "2"}
+
+	assert.True(t, And(Leaf(a), Leaf(b)).IsConjunction())
+	assert.False(t, Or(Leaf(a), Leaf(b)).IsConjunction())
+	assert.False(t, And(Leaf(a), Not(Leaf(b))).IsConjunction())
+	assert.Equal(t, []Filter{a, b}, And(Leaf(a), Not(Leaf(b))).Filters())
+}
+
+func TestFilterExprValidate(t *testing.T) {
+	a := Filter{Field: "a", Operation: FilterOperatorEqual, Value: "1"}
+
+	tests := []struct {
+		name    string
+		json    string
+		wantErr bool
+	}{
+		{name: "should accept well formed expressions", json: `{"kind":"and","children":[{"kind":"not","children":[{"kind":"leaf","filter":{"field":"a"}}]}]}`},
+		{name: "should reject empty conjunctions", json: `{"kind":"and"}`, wantErr: true},
+		{name: "should reject nested empty disjunctions", json: `{"kind":"not","children":[{"kind":"or"}]}`, wantErr: true},
+		{name: "should reject leaves without filter", json: `{"kind":"leaf"}`, wantErr: true},
+		{name: "should reject nested leaves without filter", json: `{"kind":"or","children":[{"kind":"leaf"}]}`, wantErr: true},
+		{name: "should reject not without child", json: `{"kind":"not"}`, wantErr: true},
+		{name: "should reject not with many children", json: `{"kind":"not","children":[{"kind":"leaf","filter":{"field":"a"}},{"kind":"leaf","filter":{"field":"b"}}]}`, wantErr: true},
+		{name: "should reject unknown kinds", json: `{"kind":"xor"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var e FilterExpr
+			assert.Nil(t, json.Unmarshal([]byte(tt.json), &e))
+
+			err := e.Validate()
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrInvalidExpr), "got: %v", err)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+
+	assert.Nil(t, And(Leaf(a), Not(Or(Leaf(a)))).Validate())
+	assert.Empty(t, Apply([]map[string]interface{}{{"a": "1"}}, Query{Expr: &FilterExpr{Kind: FilterExprNot}}))
+}
+
+func TestParseWithGrouping(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := parse(url.Values{"filters": {"price[gt]10,price[lt]20"}}, Options{Grouping: true, Schema: testSchema})
+	expect.Nil(err)
+	expect.Equal(FilterExprAnd, got.Expr.Kind)
+	expect.Equal([]Filter{
+		{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)},
+		{Field: "price", Operation: FilterOperatorLessThan, Value: "20", Typed: int64(20)},
+	}, got.Filters)
+
+	got, err = parse(url.Values{"filters": {"price[gt]10|price[lt]2"}}, Options{Grouping: true})
+	expect.Nil(err)
+	expect.Equal(FilterExprOr, got.Expr.Kind)
+	expect.Empty(got.Filters)
+
+	_, err = parse(url.Values{"filters": {"price[gt]10|name[eq]a"}}, Options{Grouping: true, Schema: testSchema})
+	expect.True(errors.Is(err, ErrOperatorNotAllowed))
+}
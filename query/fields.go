@@ -0,0 +1,178 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrFieldNotSelectable = errors.New("field is not selectable")
+
+// Separates the names of a nested field path, eg: "customer.name"
+const FieldPathSeparator = "."
+
+// Checks that the field path, or one of its parents, is declared as selectable,
+// eg: "customer.name" is allowed when "customer" is selectable
+func (s *Schema) ValidateSelect(path string) error {
+	for p := path; ; {
+		if field, ok := s.Fields[p]; ok && field.Selectable {
+			return nil
+		}
+
+		i := strings.LastIndex(p, FieldPathSeparator)
+		if i == -1 {
+			return fmt.Errorf("%w: %q", ErrFieldNotSelectable, path)
+		}
+		p = p[:i]
+	}
+}
+
+// Returns the field paths of the fields key, eg: "fields=id,customer.name" -> ["id", "customer.name"].
+// Returns an empty slice, meaning every field, when the query has no fields.
+func GetFieldsFromQuery(c *fiber.Ctx) []string {
+	queryParams := queryArgsToValues(c)
+	fields, _ := parseFields(queryParams[string(QueryKeyFields)], nil, false)
+	return fields
+}
+
+// Same as GetFieldsFromQuery, but returns an error when a field is not selectable on the schema
+func GetFieldsFromQueryWithSchema(c *fiber.Ctx, schema *Schema) ([]string, error) {
+	queryParams := queryArgsToValues(c)
+	fields, errs := parseFields(queryParams[string(QueryKeyFields)], schema, false)
+	return fields, errs.orNil()
+}
+
+// Parses the field paths of the fields query values, dropping repeated ones.
+// Malformed paths are dropped, or reported when strict. Paths the schema does not allow are always reported.
+func parseFields(values []string, schema *Schema, strict bool) ([]string, ParseErrors) {
+	fields := []string{}
+	errs := ParseErrors{}
+	seen := map[string]bool{}
+
+	for _, segment := range splitRepeatedSegments(values, QueryParamSeparatorMap) {
+		path, err := parseFieldPath(segment.text)
+		if err != nil {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyFields, err))
+			}
+			continue
+		}
+
+		if schema != nil {
+			if err := schema.ValidateSelect(path); err != nil {
+				errs = append(errs, segment.error(QueryKeyFields, err))
+				continue
+			}
+		}
+
+		if !seen[path] {
+			seen[path] = true
+			fields = append(fields, path)
+		}
+	}
+
+	return fields, errs
+}
+
+func parseFieldPath(value string) (string, error) {
+	path := unescape(value, false)
+
+	for _, name := range strings.Split(path, FieldPathSeparator) {
+		if !hasALetter(name) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidField, path)
+		}
+	}
+
+	return path, nil
+}
+
+// Encodes the field paths as a fields query value, eg: "id,customer.name"
+func EncodeFields(fields []string) string {
+	encoded := make([]string, 0, len(fields))
+	for _, f := range fields {
+		encoded = append(encoded, escape(f, fieldsSpecials))
+	}
+	return strings.Join(encoded, string(QueryParamSeparatorMap))
+}
+
+// Tree of the requested field paths, where a nil subtree keeps the whole value
+type fieldTree map[string]fieldTree
+
+func newFieldTree(fields []string) fieldTree {
+	tree := fieldTree{}
+
+	for _, f := range fields {
+		node := tree
+		names := strings.Split(f, FieldPathSeparator)
+
+		for i, name := range names {
+			child, ok := node[name]
+			if ok && child == nil {
+				break // a parent is already kept whole
+			}
+
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+
+			if !ok {
+				child = fieldTree{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	return tree
+}
+
+// Removes from a marshaled JSON value every field not in fields, for backends that can't project.
+// Arrays are pruned element by element, eg: PruneJSON(`[{"id":1,"name":"a","price":2}]`, ["id", "name"])
+// -> `[{"id":1,"name":"a"}]`. The value is returned as is when fields is empty.
+func PruneJSON(data []byte, fields []string) ([]byte, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(newFieldTree(fields).prune(v))
+}
+
+func (t fieldTree) prune(v interface{}) interface{} {
+	switch value := v.(type) {
+	case []interface{}:
+		for i, element := range value {
+			value[i] = t.prune(element)
+		}
+		return value
+	case map[string]interface{}:
+		pruned := map[string]interface{}{}
+		for name, subtree := range t {
+			child, ok := value[name]
+			if !ok {
+				continue
+			}
+
+			if subtree == nil {
+				pruned[name] = child
+				continue
+			}
+			pruned[name] = subtree.prune(child)
+		}
+		return pruned
+	}
+
+	return v
+}
@@ -0,0 +1,119 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	schema := &Schema{
+		Fields: map[string]Field{
+			"id":       {Selectable: true},
+			"customer": {Selectable: true},
+			"price":    {Sortable: true},
+		},
+	}
+
+	type args struct {
+		value  string
+		schema *Schema
+		strict bool
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []string
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should parse field paths",
+			args: args{value: `id,customer.name,a\,b`},
+			want: []string{"id", "customer.name", "a,b"},
+		},
+		{
+			name: "should drop repeated and malformed paths when not strict",
+			args: args{value: "id,,customer..name,id"},
+			want: []string{"id"},
+		},
+		{
+			name: "should report malformed paths when strict",
+			args: args{value: "id,customer.", strict: true},
+			want: []string{"id"},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFields, Segment: "customer.", Position: 3, Err: ErrInvalidField},
+			},
+		},
+		{
+			name: "should allow nested paths of selectable fields",
+			args: args{value: "id,customer.address.city,price", schema: schema},
+			want: []string{"id", "customer.address.city"},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFields, Segment: "price", Position: 25, Err: ErrFieldNotSelectable},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := parseFields([]string{tt.args.value}, tt.args.schema, tt.args.strict)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, errs.orNil())
+		})
+	}
+}
+
+func TestPruneJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "should keep every field when there are none",
+			data:   `{"id":1,"name":"a"}`,
+			fields: nil,
+			want:   `{"id":1,"name":"a"}`,
+		},
+		{
+			name:   "should prune each element of arrays",
+			data:   `[{"id":1,"name":"a","price":2.50},{"id":2,"price":3}]`,
+			fields: []string{"id", "price"},
+			want:   `[{"id":1,"price":2.50},{"id":2,"price":3}]`,
+		},
+		{
+			name:   "should prune nested paths",
+			data:   `{"id":1,"customer":{"name":"a","email":"b","address":{"city":"c","zip":"d"}},"tags":[{"id":1,"name":"x"}]}`,
+			fields: []string{"customer.name", "customer.address", "tags.name"},
+			want:   `{"customer":{"name":"a","address":{"city":"c","zip":"d"}},"tags":[{"name":"x"}]}`,
+		},
+		{
+			name:   "should keep parents whole when also requested",
+			data:   `{"customer":{"name":"a","email":"b"}}`,
+			fields: []string{"customer.name", "customer"},
+			want:   `{"customer":{"name":"a","email":"b"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PruneJSON([]byte(tt.data), tt.fields)
+			assert.Nil(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+
+	_, err := PruneJSON([]byte("{"), []string{"id"})
+	assert.NotNil(t, err)
+}
+
+func TestValidateSelect(t *testing.T) {
+	schema := &Schema{Fields: map[string]Field{"customer.name": {Selectable: true}}}
+
+	assert.Nil(t, schema.ValidateSelect("customer.name"))
+	assert.True(t, errors.Is(schema.ValidateSelect("customer"), ErrFieldNotSelectable))
+	assert.True(t, errors.Is(schema.ValidateSelect("customer.email"), ErrFieldNotSelectable))
+}
@@ -0,0 +1,232 @@
+package query
+
+import (
+	"errors"
+	"strings"
+)
+
+// Characters that make the next character, or the enclosed ones, lose any special meaning,
+// eg: "name[eq]Smith\, John" and "name[eq]\"Smith, John\"" both filter by "Smith, John"
+const (
+	QueryParamEscape = `\`
+	QueryParamQuote  = `"`
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	ErrDanglingEscape    = errors.New("escape character at the end")
+)
+
+// Characters an escape character makes lose their special meaning. Escape characters before any
+// other character are kept as they are, eg: "C:\dir"
+const escapableSpecials = `\,;:[]"|()!`
+
+// Characters around the tokens a pair of quotes must wrap to quote them, eg: the value of `name[eq]"Smith, John"`.
+// Quotes anywhere else are kept as they are, eg: `title[contains]5" screen`.
+const quoteDelimiters = `,;:[]|()!`
+
+// Where the escape characters and quotes of a string are, see analyzeQuoting
+type quoting struct {
+	escapes []bool // escapes[i] when s[i] escapes s[i+1]
+	quotes  []bool // quotes[i] when s[i] opens or closes a quote
+}
+
+// Finds the escapes and quotes of s. Quotes open at the start of s or after one of delimiters, and only
+// when a closing one follows, right before the end of s or one of delimiters.
+func analyzeQuoting(s string, delimiters string) quoting {
+	q := quoting{escapes: make([]bool, len(s)), quotes: make([]bool, len(s))}
+	escaped := make([]bool, len(s))
+
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] == QueryParamEscape[0] && strings.IndexByte(escapableSpecials, s[i+1]) != -1 {
+			q.escapes[i] = true
+			escaped[i+1] = true
+			i++
+		}
+	}
+
+	isDelimiter := func(i int) bool {
+		return !escaped[i] && strings.IndexByte(delimiters, s[i]) != -1
+	}
+
+	// closers[i] when a quote that can close one is at i or after it
+	closers := make([]bool, len(s)+1)
+	for i := len(s) - 1; i >= 0; i-- {
+		closes := s[i] == QueryParamQuote[0] && !escaped[i] && (i+1 == len(s) || isDelimiter(i+1))
+		closers[i] = closes || closers[i+1]
+	}
+
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		if s[i] != QueryParamQuote[0] || escaped[i] {
+			continue
+		}
+
+		switch {
+		case inQuote && (i+1 == len(s) || isDelimiter(i+1)):
+			inQuote = false
+			q.quotes[i] = true
+		case !inQuote && (i == 0 || isDelimiter(i-1)) && closers[i+1]:
+			inQuote = true
+			q.quotes[i] = true
+		}
+	}
+
+	return q
+}
+
+// Calls fn with the index of each sep of s that is neither escaped nor, when quotes is true,
+// between quotes, until fn returns false
+func scanUnescaped(s string, sep QueryParamSeparator, quotes bool, fn func(i int) bool) {
+	q := analyzeQuoting(s, quoteDelimiters)
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case q.escapes[i]:
+			i++
+		case quotes && q.quotes[i]:
+			inQuote = !inQuote
+		case !inQuote && strings.HasPrefix(s[i:], string(sep)):
+			if !fn(i) {
+				return
+			}
+		}
+	}
+}
+
+// Index of the first sep of s that is neither escaped nor quoted, -1 when there is none
+func indexUnescaped(s string, sep QueryParamSeparator) int {
+	index := -1
+
+	scanUnescaped(s, sep, true, func(i int) bool {
+		index = i
+		return false
+	})
+
+	return index
+}
+
+// Splits s around each sep that is neither escaped nor, when quotes is true, quoted.
+// Escapes and quotes are kept on the parts.
+func splitUnescaped(s string, sep QueryParamSeparator, quotes bool) []string {
+	parts := []string{}
+	start := 0
+
+	scanUnescaped(s, sep, quotes, func(i int) bool {
+		parts = append(parts, s[start:i])
+		start = i + len(sep)
+		return true
+	})
+
+	return append(parts, s[start:])
+}
+
+// Removes the quotes wrapping s and escapes from s. When list is true, the result is still a list of values
+// separated by QueryParamSeparatorArray, whose values may be quoted on their own: escaped or quoted array
+// separators, escape characters and quote characters are kept escaped, so Filter.Values can tell the values apart.
+func unescape(s string, list bool) string {
+	delimiters := ""
+	if list {
+		delimiters = string(QueryParamSeparatorArray)
+	}
+	q := analyzeQuoting(s, delimiters)
+
+	var b strings.Builder
+	inQuote := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i : i+1]
+
+		switch {
+		case q.escapes[i]:
+			i++
+			c = s[i : i+1]
+			if list && (c == string(QueryParamSeparatorArray) || c == QueryParamEscape || c == QueryParamQuote) {
+				b.WriteString(QueryParamEscape)
+			}
+			b.WriteString(c)
+		case q.quotes[i]:
+			inQuote = !inQuote
+		case list && (c == QueryParamEscape || c == QueryParamQuote || (inQuote && c == string(QueryParamSeparatorArray))):
+			b.WriteString(QueryParamEscape + c)
+		default:
+			b.WriteString(c)
+		}
+	}
+
+	return b.String()
+}
+
+// Prefixes every character of specials on s with an escape character
+func escape(s string, specials string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		if strings.ContainsRune(specials, r) {
+			b.WriteString(QueryParamEscape)
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// Characters escaped by the encoders, including the filters expression ones so encoded filters
+// can be parsed by both grammars
+const (
+	filterFieldSpecials     = `\,[]":|()!`
+	filterValueSpecials     = `\,"|()`
+	filterListValueSpecials = `,|()` // list values are already escaped by JoinValues
+	orderFieldSpecials      = `\,:"`
+	fieldsSpecials          = `\,"`
+	listValueSpecials       = `\;"`
+)
+
+// Encodes the filter the way getFilter parses it, escaping what would be interpreted,
+// eg: Filter{Field: "name", Operation: "eq", Value: "Smith, John"} -> "name[eq]Smith\, John"
+func EncodeFilter(f Filter) string {
+	value := escape(f.Value, filterValueSpecials)
+	if f.Operation.isList() {
+		value = escape(f.Value, filterListValueSpecials)
+	}
+
+	if value == "" && f.Operation.Arity() != ArityNone {
+		value = QueryParamQuote + QueryParamQuote
+	}
+
+	return escape(f.Field, filterFieldSpecials) +
+		string(QueryParamSeparatorOperatorStart) + string(f.Operation) + string(QueryParamSeparatorOperatorEnd) +
+		value
+}
+
+// Encodes the filters as a filters query value, eg: "price[gt]10,name[eq]Smith\, John"
+func EncodeFilters(filters []Filter) string {
+	encoded := make([]string, 0, len(filters))
+	for _, f := range filters {
+		encoded = append(encoded, EncodeFilter(f))
+	}
+	return strings.Join(encoded, string(QueryParamSeparatorMap))
+}
+
+// Encodes the orders as an order query value, eg: "price:asc,name:desc"
+func EncodeOrders(orders []Order) string {
+	encoded := make([]string, 0, len(orders))
+	for _, o := range orders {
+		direction := "desc"
+		if o.Asc {
+			direction = "asc"
+		}
+		encoded = append(encoded, escape(o.Field, orderFieldSpecials)+string(QueryParamSeparatorValue)+direction)
+	}
+	return strings.Join(encoded, string(QueryParamSeparatorMap))
+}
+
+// Joins values into the value of a list operator filter, eg: ["a;b", "c"] -> "a\;b;c"
+func JoinValues(values []string) string {
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, escape(v, listValueSpecials))
+	}
+	return strings.Join(escaped, string(QueryParamSeparatorArray))
+}
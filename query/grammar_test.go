@@ -0,0 +1,196 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFilterFromQueryWithEscapes(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name string
+		args args
+		want []Filter
+	}{
+		{
+			name: "should keep escaped separators on the value",
+			args: args{queryParams: url.Values{
+				"filters": {`name[eq]Smith\, John,age[gt]10`},
+			}},
+			want: []Filter{
+				{Field: "name", Operation: FilterOperatorEqual, Value: "Smith, John"},
+				{Field: "age", Operation: FilterOperatorGreaterThan, Value: "10"},
+			},
+		},
+		{
+			name: "should keep quoted separators on the value",
+			args: args{queryParams: url.Values{
+				"filters": {`name[eq]"Smith, John",title[contains]"[draft]"`},
+			}},
+			want: []Filter{
+				{Field: "name", Operation: FilterOperatorEqual, Value: "Smith, John"},
+				{Field: "title", Operation: FilterOperatorContains, Value: "[draft]"},
+			},
+		},
+		{
+			name: "should keep escaped quotes and escapes on the value",
+			args: args{queryParams: url.Values{
+				"filters": {`title[eq]"say \"hi\"",path[startsWith]C:\\dir`},
+			}},
+			want: []Filter{
+				{Field: "title", Operation: FilterOperatorEqual, Value: `say "hi"`},
+				{Field: "path", Operation: FilterOperatorStartsWith, Value: `C:\dir`},
+			},
+		},
+		{
+			name: "should allow escaped brackets on the field",
+			args: args{queryParams: url.Values{
+				"filters": {`a\[0\][eq]b`},
+			}},
+			want: []Filter{
+				{Field: "a[0]", Operation: FilterOperatorEqual, Value: "b"},
+			},
+		},
+		{
+			name: "should allow quoted empty values",
+			args: args{queryParams: url.Values{
+				"filters": {`a[eq]""`},
+			}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorEqual, Value: ""},
+			},
+		},
+		{
+			name: "should keep list escapes on in values",
+			args: args{queryParams: url.Values{
+				"filters": {`tags[in]a\;b;"c;d";e\,f`},
+			}},
+			want: []Filter{
+				{Field: "tags", Operation: FilterOperatorIn, Value: `a\;b;c\;d;e,f`},
+			},
+		},
+		{
+			name: "should keep unbalanced quotes and dangling escapes literal",
+			args: args{queryParams: url.Values{
+				"filters": {`a[eq]"b,c[eq]d\`},
+			}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorEqual, Value: `"b`},
+				{Field: "c", Operation: FilterOperatorEqual, Value: `d\`},
+			},
+		},
+		{
+			name: "should keep quotes inside the value literal",
+			args: args{queryParams: url.Values{
+				"filters": {`title[contains]5" screen`},
+			}},
+			want: []Filter{
+				{Field: "title", Operation: FilterOperatorContains, Value: `5" screen`},
+			},
+		},
+		{
+			name: "should keep escapes before ordinary characters literal",
+			args: args{queryParams: url.Values{
+				"filters": {`path[eq]C:\dir`},
+			}},
+			want: []Filter{
+				{Field: "path", Operation: FilterOperatorEqual, Value: `C:\dir`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getFilterFromQuery(tt.args.queryParams)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestGetOrderFromQueryWithEscapes(t *testing.T) {
+	got := getOrderFromQuery(url.Values{"order": {`a\:b:asc,"c,d":desc`}})
+	want := []Order{{Field: "a:b", Asc: true}, {Field: "c,d", Asc: false}}
+	assert.True(t, reflect.DeepEqual(want, got), "got: %v, want: %v", got, want)
+}
+
+func TestFilterValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "should split on array separator",
+			value: "a;b",
+			want:  []string{"a", "b"},
+		},
+		{
+			name:  "should keep escaped separators on the values",
+			value: `a\;b;c\\;d\"`,
+			want:  []string{"a;b", `c\`, `d"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Filter{Field: "a", Operation: FilterOperatorIn, Value: tt.value}.Values()
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncodeFilters(t *testing.T) {
+	filters := []Filter{
+		{Field: "name", Operation: FilterOperatorEqual, Value: "Smith, John"},
+		{Field: "title", Operation: FilterOperatorContains, Value: `[draft] "x" \ y`},
+		{Field: "a[0]:b", Operation: FilterOperatorEqual, Value: ""},
+		{Field: "tags", Operation: FilterOperatorIn, Value: JoinValues([]string{"a;b", "c,d", `e"\`})},
+	}
+
+	encoded := EncodeFilters(filters)
+	assert.Equal(t, `name[eq]Smith\, John,title[contains][draft] \"x\" \\ y,a\[0\]\:b[eq]"",tags[in]a\;b;c\,d;e\"\\`, encoded)
+
+	got, err := getFilterFromQueryStrict(url.Values{"filters": {encoded}})
+	assert.Nil(t, err)
+	assert.True(t, reflect.DeepEqual(filters, got), "got: %v, want: %v", got, filters)
+	assert.Equal(t, []string{"a;b", "c,d", `e"\`}, got[3].Values())
+}
+
+func TestEncodeOrders(t *testing.T) {
+	orders := []Order{{Field: "price", Asc: true}, {Field: "a:b,c", Asc: false}}
+
+	encoded := EncodeOrders(orders)
+	assert.Equal(t, `price:asc,a\:b\,c:desc`, encoded)
+
+	got, err := getOrderFromQueryStrict(url.Values{"order": {encoded}})
+	assert.Nil(t, err)
+	assert.True(t, reflect.DeepEqual(orders, got), "got: %v, want: %v", got, orders)
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		list  bool
+		want  string
+	}{
+		{name: "should unquote wrapping quotes", value: `"a,b"`, want: "a,b"},
+		{name: "should keep an unterminated quote", value: `"abc`, want: `"abc`},
+		{name: "should keep a quote inside the text", value: `5" screen`, want: `5" screen`},
+		{name: "should keep a dangling escape", value: `abc\`, want: `abc\`},
+		{name: "should keep escapes before ordinary characters", value: `C:\dir`, want: `C:\dir`},
+		{name: "should unescape special characters", value: `a\,b\"c\`, want: `a,b"c\`},
+		{name: "should keep list escapes", value: `a\;b;"c;d";e\`, list: true, want: `a\;b;c\;d;e\\`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, unescape(tt.value, tt.list))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package query
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Same as Parse, but framework agnostic, eg: for values decoded by chi or gRPC-gateway.
+// Repeated keys are read as Parse reads them.
+func ParseValues(values url.Values, opts Options) (Query, error) {
+	return parseArgs(valuesToArgs(values), opts)
+}
+
+// Same as Parse, but for net/http requests
+func ParseRequest(r *http.Request, opts Options) (Query, error) {
+	return parseArgs(splitQueryString(r.URL.RawQuery), opts)
+}
+
+// Decodes a raw query string the way fiber does. Unlike url.ParseQuery, pairs are only separated
+// by "&", as ";" separates the values of list filters, eg: "filters=tags[in]a;b", and malformed
+// escapes are kept as they are instead of dropping the pair.
+func ParseQueryString(raw string) url.Values {
+	return argsToValues(splitQueryString(raw))
+}
+
+// Same as ParseQueryString, but keeps the order of the pairs
+func splitQueryString(raw string) []queryArg {
+	args := []queryArg{}
+
+	for raw != "" {
+		var pair string
+		pair, raw, _ = strings.Cut(raw, "&")
+		if pair == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(pair, "=")
+		args = append(args, queryArg{key: unescapeQuery(key), value: unescapeQuery(value)})
+	}
+
+	return args
+}
+
+func unescapeQuery(s string) string {
+	unescaped, err := url.QueryUnescape(s)
+	if err != nil {
+		return s
+	}
+	return unescaped
+}
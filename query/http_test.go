@@ -0,0 +1,50 @@
+package query
+
+import (
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+// The same query string must be parsed the same way by every adapter
+func TestParseRequestMatchesFiber(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+	}{
+		{name: "should parse every key", rawQuery: "limit=5&offset=10&filters=a%5Beq%5Db&order=c:desc&search=x"},
+		{name: "should keep list separators", rawQuery: "filters=tags[in]a;b;c,price[between]1;2"},
+		{name: "should decode spaces and escapes", rawQuery: "search=a+b%20c&filters=name[eq]Smith%5C%2C%20John"},
+		{name: "should keep the last value of repeated pagination keys", rawQuery: "limit=5&limit=7&offset=1&offset=2"},
+		{name: "should merge repeated list keys", rawQuery: "filters=a[eq]1&filters=b[eq]2&order=a:asc&order=b:desc&fields=a&fields=b"},
+		{name: "should keep malformed escapes", rawQuery: "search=100%zz&&flag"},
+	}
+
+	app := fiber.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fctx := &fasthttp.RequestCtx{}
+			fctx.Request.SetRequestURI("/products?" + tt.rawQuery)
+			ctx := app.AcquireCtx(fctx)
+			defer app.ReleaseCtx(ctx)
+
+			want, wantErr := Parse(ctx, Options{Strict: true})
+
+			got, err := ParseRequest(httptest.NewRequest("GET", "/products?"+tt.rawQuery, nil), Options{Strict: true})
+			assert.Equal(t, wantErr, err)
+			assert.True(t, reflect.DeepEqual(want, got), "got: %v, want: %v", got, want)
+		})
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	got, err := ParseValues(ParseQueryString("filters=tags[in]a;b&search=x"), Options{})
+	assert.Nil(t, err)
+	assert.Equal(t, []Filter{{Field: "tags", Operation: FilterOperatorIn, Value: "a;b"}}, got.Filters)
+	assert.Equal(t, "x", got.Search)
+}
@@ -0,0 +1,157 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrIncludeNotAllowed = errors.New("relation can not be included")
+	ErrIncludeTooDeep    = errors.New("relation path is too deep")
+)
+
+// Maximum amount of relations on an include path when the schema sets none, eg: "items.product.vendor"
+const DefaultMaxIncludeDepth = 3
+
+// Relation to expand, along with the relations to expand on it
+type Include struct {
+	Relation string   `json:"relation"`           // eg: "items"
+	Includes Includes `json:"includes,omitempty"` // eg: "product" for "items.product"
+}
+
+// Tree of relations to expand, eg: "include=customer,items.product" -> [customer, items -> [product]]
+type Includes []Include
+
+func (in *Includes) add(relations []string) {
+	if len(relations) == 0 {
+		return
+	}
+
+	for i := range *in {
+		if (*in)[i].Relation == relations[0] {
+			(*in)[i].Includes.add(relations[1:])
+			return
+		}
+	}
+
+	include := Include{Relation: relations[0]}
+	include.Includes.add(relations[1:])
+	*in = append(*in, include)
+}
+
+// Returns whether the relation path was requested, eg: "items" and "items.product" for "include=items.product"
+func (in Includes) Has(path string) bool {
+	_, ok := in.Get(path)
+	return ok
+}
+
+// Returns the include of the relation path, whose Includes are the relations to expand on it
+func (in Includes) Get(path string) (Include, bool) {
+	relation, rest, nested := strings.Cut(path, FieldPathSeparator)
+
+	for _, include := range in {
+		if include.Relation != relation {
+			continue
+		}
+
+		if nested {
+			return include.Includes.Get(rest)
+		}
+		return include, true
+	}
+
+	return Include{}, false
+}
+
+// The deepest relation paths of the tree, which imply their parents, eg: ["customer", "items.product"]
+func (in Includes) Paths() []string {
+	paths := []string{}
+
+	for _, include := range in {
+		if len(include.Includes) == 0 {
+			paths = append(paths, include.Relation)
+			continue
+		}
+
+		for _, p := range include.Includes.Paths() {
+			paths = append(paths, include.Relation+FieldPathSeparator+p)
+		}
+	}
+
+	return paths
+}
+
+// Checks that the relation path is declared on the schema includes, or is the parent of one of them
+func (s *Schema) ValidateInclude(path string) error {
+	for _, allowed := range s.Includes {
+		if allowed == path || strings.HasPrefix(allowed, path+FieldPathSeparator) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrIncludeNotAllowed, path)
+}
+
+func (s *Schema) maxIncludeDepth() int {
+	if s == nil || s.MaxIncludeDepth == 0 {
+		return DefaultMaxIncludeDepth
+	}
+	return s.MaxIncludeDepth
+}
+
+// Returns the relations to expand from the include key, dropping malformed and too deep paths
+func GetIncludesFromQuery(c *fiber.Ctx) Includes {
+	queryParams := queryArgsToValues(c)
+	includes, _ := parseIncludes(queryParams[string(QueryKeyInclude)], nil, false)
+	return includes
+}
+
+// Same as GetIncludesFromQuery, but returns an error when a relation path is too deep or not allowed by the schema
+func GetIncludesFromQueryWithSchema(c *fiber.Ctx, schema *Schema) (Includes, error) {
+	queryParams := queryArgsToValues(c)
+	includes, errs := parseIncludes(queryParams[string(QueryKeyInclude)], schema, false)
+	return includes, errs.orNil()
+}
+
+// Parses the relation paths of the include query values into a tree.
+// Malformed paths are dropped, or reported when strict. Paths too deep or, when a schema is given,
+// not allowed by it are always reported.
+func parseIncludes(values []string, schema *Schema, strict bool) (Includes, ParseErrors) {
+	includes := Includes{}
+	errs := ParseErrors{}
+
+	for _, segment := range splitRepeatedSegments(values, QueryParamSeparatorMap) {
+		path, err := parseFieldPath(segment.text)
+		if err != nil {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyInclude, err))
+			}
+			continue
+		}
+
+		relations := strings.Split(path, FieldPathSeparator)
+		if max := schema.maxIncludeDepth(); len(relations) > max {
+			errs = append(errs, segment.error(QueryKeyInclude, fmt.Errorf("%w: maximum is %d", ErrIncludeTooDeep, max)))
+			continue
+		}
+
+		if schema != nil {
+			if err := schema.ValidateInclude(path); err != nil {
+				errs = append(errs, segment.error(QueryKeyInclude, err))
+				continue
+			}
+		}
+
+		includes.add(relations)
+	}
+
+	return includes, errs
+}
+
+// Encodes the tree as an include query value, eg: "customer,items.product"
+func EncodeIncludes(includes Includes) string {
+	return EncodeFields(includes.Paths())
+}
@@ -0,0 +1,88 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIncludes(t *testing.T) {
+	schema := &Schema{Includes: []string{"customer.address", "items.product"}, MaxIncludeDepth: 2}
+
+	type args struct {
+		value  string
+		schema *Schema
+		strict bool
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     Includes
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should parse relation paths into a tree",
+			args: args{value: "customer,items.product,items.product.vendor,customer.address"},
+			want: Includes{
+				{Relation: "customer", Includes: Includes{{Relation: "address"}}},
+				{Relation: "items", Includes: Includes{{Relation: "product", Includes: Includes{{Relation: "vendor"}}}}},
+			},
+		},
+		{
+			name: "should report paths deeper than the default maximum",
+			args: args{value: "a.b.c.d"},
+			want: Includes{},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyInclude, Segment: "a.b.c.d", Err: ErrIncludeTooDeep},
+			},
+		},
+		{
+			name: "should report malformed paths when strict",
+			args: args{value: "customer,.items", strict: true},
+			want: Includes{{Relation: "customer"}},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyInclude, Segment: ".items", Position: 9, Err: ErrInvalidField},
+			},
+		},
+		{
+			name: "should report paths the schema does not allow",
+			args: args{value: "customer,items.product,orders,customer.address.city", schema: schema},
+			want: Includes{
+				{Relation: "customer"},
+				{Relation: "items", Includes: Includes{{Relation: "product"}}},
+			},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyInclude, Segment: "orders", Position: 23, Err: ErrIncludeNotAllowed},
+				{Key: QueryKeyInclude, Segment: "customer.address.city", Position: 30, Err: ErrIncludeTooDeep},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := parseIncludes([]string{tt.args.value}, tt.args.schema, tt.args.strict)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, errs.orNil())
+		})
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	expect := assert.New(t)
+
+	includes, _ := parseIncludes([]string{"customer,items.product"}, nil, false)
+
+	expect.True(includes.Has("customer"))
+	expect.True(includes.Has("items"))
+	expect.True(includes.Has("items.product"))
+	expect.False(includes.Has("product"))
+	expect.False(includes.Has("customer.address"))
+
+	items, ok := includes.Get("items")
+	expect.True(ok)
+	expect.Equal(Includes{{Relation: "product"}}, items.Includes)
+
+	expect.Equal([]string{"customer", "items.product"}, includes.Paths())
+	expect.Equal("customer,items.product", EncodeIncludes(includes))
+}
@@ -0,0 +1,279 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrInvalidKey = errors.New("malformed bracketed key")
+
+// Query keys of the JSON:API style parameters, eg: "filter[price][gt]=10&filter[tag][in]=a,b&sort=-createdAt,name&page[limit]=20&page[offset]=40"
+const (
+	QueryKeyJSONAPIFilter QueryKey = "filter"
+	QueryKeyJSONAPISort   QueryKey = "sort"
+	QueryKeyJSONAPIPage   QueryKey = "page"
+)
+
+// Members of the page key, eg: "page[limit]", and the pagination keys they stand for
+var jsonapiPageMembers = map[string]QueryKey{
+	"limit":  QueryKeyLimit,
+	"offset": QueryKeyOffset,
+	"number": QueryKeyPage,
+	"size":   QueryKeyPageSize,
+	"cursor": QueryKeyCursor,
+}
+
+// Characters escaped on the values of list operators and on sort fields, as they are separated by commas
+const jsonapiListSpecials = `\,"`
+
+// Same as Parse, but reads JSON:API style keys instead: every "filter[field][op]=value" becomes one of
+// Query.Filters, "filter[field]=value" meaning eq, "sort=-createdAt,name" becomes Query.Orders, descending
+// when prefixed by "-", and "page[limit]", "page[offset]", "page[number]", "page[size]" and "page[cursor]"
+// become Query.Pagination. The search, fields and include keys are read as Parse does.
+// Repeated filter keys are all kept, eg: "filter[price][gt]=10&filter[price][lt]=20", while repeated sort,
+// fields and include keys are read as Options.RepeatedKeys says.
+// Values of list operators are separated by commas, eg: "filter[tag][in]=a,b", which can be escaped or quoted.
+func ParseJSONAPI(ctx *fiber.Ctx, opts Options) (Query, error) {
+	return parseJSONAPI(queryArgs(ctx), opts)
+}
+
+func parseJSONAPI(args []queryArg, opts Options) (Query, error) {
+	errs := ParseErrors{}
+	filters := []Filter{}
+	page := url.Values{}
+	pageKeys := map[QueryKey]QueryKey{}
+	rest := url.Values{}
+
+	for _, arg := range args {
+		key := QueryKey(arg.key)
+		value := segment{text: arg.value}
+
+		name, members, ok := splitBracketedKey(arg.key)
+		switch {
+		case !ok && (name == string(QueryKeyJSONAPIFilter) || name == string(QueryKeyJSONAPIPage)):
+			if opts.Strict {
+				errs = append(errs, value.error(key, ErrInvalidKey))
+			}
+		case name == string(QueryKeyJSONAPIFilter):
+			f, err := getJSONAPIFilter(members, arg.value, opts.Schema != nil)
+			if err != nil {
+				if opts.Strict {
+					errs = append(errs, value.error(key, err))
+				}
+				continue
+			}
+
+			if opts.Schema != nil {
+				f, err = opts.Schema.CoerceFilter(f)
+				if err != nil {
+					errs = append(errs, value.error(key, err))
+					continue
+				}
+			}
+
+			filters = append(filters, f)
+		case name == string(QueryKeyJSONAPIPage):
+			var member QueryKey
+			if len(members) == 1 {
+				member, ok = jsonapiPageMembers[members[0]]
+			}
+			if len(members) != 1 || !ok {
+				if opts.Strict {
+					errs = append(errs, value.error(key, ErrInvalidKey))
+				}
+				continue
+			}
+
+			page.Add(string(member), arg.value)
+			pageKeys[member] = key
+		default:
+			rest.Add(arg.key, arg.value)
+		}
+	}
+
+	sortValues, keyErrs := repeatedValues(rest, QueryKeyJSONAPISort, opts.RepeatedKeys)
+	errs = append(errs, keyErrs...)
+	orders, sortErrs := parseJSONAPISort(sortValues, opts.Schema, opts.Strict)
+	errs = append(errs, sortErrs...)
+
+	pagination, paginationErrs := parsePagination(page, opts)
+	for _, err := range paginationErrs {
+		err.Key = pageKeys[err.Key]
+		errs = append(errs, err)
+	}
+
+	q := Query{
+		Pagination: pagination,
+		Filters:    filters,
+		Orders:     orders,
+	}
+	errs = append(errs, parseSelection(rest, opts, &q)...)
+
+	return q, errs.orNil()
+}
+
+// eg: "filter[price][gt]" -> "filter", ["price", "gt"]. Reports false when the brackets are unbalanced
+// or followed by anything other than another bracket.
+func splitBracketedKey(key string) (string, []string, bool) {
+	start := strings.Index(key, string(QueryParamSeparatorOperatorStart))
+	if start == -1 {
+		return key, nil, true
+	}
+
+	name, rest := key[:start], key[start:]
+	members := []string{}
+
+	for rest != "" {
+		end := strings.Index(rest, string(QueryParamSeparatorOperatorEnd))
+		if !strings.HasPrefix(rest, string(QueryParamSeparatorOperatorStart)) || end == -1 {
+			return name, nil, false
+		}
+
+		members = append(members, rest[1:end])
+		rest = rest[end+1:]
+	}
+
+	return name, members, true
+}
+
+// eg: ["price", "gt"] and "10" -> Filter{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"}.
+// The regex operator is only accepted when allowRegex is true.
+func getJSONAPIFilter(members []string, value string, allowRegex bool) (Filter, error) {
+	if len(members) == 0 || len(members) > 2 {
+		return Filter{}, ErrInvalidKey
+	}
+
+	o := FilterOperatorEqual
+	if len(members) == 2 {
+		o = FilterOperator(members[1])
+		if !o.IsValid() && !(allowRegex && o == FilterOperatorRegex) {
+			return Filter{}, fmt.Errorf("%w: %q", ErrUnknownOperator, o)
+		}
+	}
+
+	if !hasALetter(members[0]) {
+		return Filter{}, ErrInvalidField
+	}
+
+	f := Filter{Field: members[0], Operation: o}
+
+	switch {
+	case o.Arity() == ArityNone:
+		if value != "" {
+			return Filter{}, fmt.Errorf("%w: %q", ErrUnexpectedValue, o)
+		}
+		return f, nil
+	case value == "":
+		return Filter{}, ErrEmptyValue
+	case !o.isList():
+		f.Value = value
+		return f, nil
+	}
+
+	values := []string{}
+	for _, v := range splitUnescaped(value, QueryParamSeparatorMap, true) {
+		values = append(values, unescape(v, false))
+	}
+
+	if o.Arity() == ArityTwo && len(values) != 2 {
+		return Filter{}, fmt.Errorf("%w: %q takes 2", ErrValueCount, o)
+	}
+
+	f.Value = JoinValues(values)
+	return f, nil
+}
+
+// eg: "-createdAt,name" -> [Order{Field: "createdAt", Asc: false}, Order{Field: "name", Asc: true}].
+// Malformed fields are dropped, or reported when strict. Fields the schema does not allow are always reported.
+func parseJSONAPISort(values []string, schema *Schema, strict bool) ([]Order, ParseErrors) {
+	orders := []Order{}
+	errs := ParseErrors{}
+
+	for _, segment := range splitRepeatedSegments(values, QueryParamSeparatorMap) {
+		o := Order{Asc: !strings.HasPrefix(segment.text, "-")}
+
+		field := unescape(strings.TrimPrefix(segment.text, "-"), false)
+		if !hasALetter(field) {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyJSONAPISort, ErrInvalidField))
+			}
+			continue
+		}
+		o.Field = field
+
+		if schema != nil {
+			if err := schema.ValidateOrder(o); err != nil {
+				errs = append(errs, segment.error(QueryKeyJSONAPISort, err))
+				continue
+			}
+		}
+
+		orders = append(orders, o)
+	}
+
+	return orders, errs
+}
+
+// Encodes the query in the keys ParseJSONAPI reads, eg: "filter[price][gt]=10&page[limit]=10&page[offset]=0&sort=-price".
+// Only Query.Filters are encoded, as the keys can only AND filters. The cursor is not encoded, as it must be signed.
+func EncodeJSONAPI(q Query) url.Values {
+	values := url.Values{}
+
+	values.Set(jsonapiKey(QueryKeyJSONAPIPage, "limit"), strconv.Itoa(q.Pagination.Limit))
+	if q.Pagination.Cursor == nil {
+		values.Set(jsonapiKey(QueryKeyJSONAPIPage, "offset"), strconv.Itoa(q.Pagination.Offset))
+	}
+
+	for _, f := range q.Filters {
+		value := f.Value
+		if f.Operation.isList() {
+			escaped := []string{}
+			for _, v := range f.Values() {
+				escaped = append(escaped, escape(v, jsonapiListSpecials))
+			}
+			value = strings.Join(escaped, string(QueryParamSeparatorMap))
+		}
+		values.Add(jsonapiKey(QueryKeyJSONAPIFilter, f.Field, string(f.Operation)), value)
+	}
+
+	if len(q.Orders) > 0 {
+		sorted := []string{}
+		for _, o := range q.Orders {
+			field := escape(o.Field, jsonapiListSpecials)
+			if !o.Asc {
+				field = "-" + field
+			}
+			sorted = append(sorted, field)
+		}
+		values.Set(string(QueryKeyJSONAPISort), strings.Join(sorted, string(QueryParamSeparatorMap)))
+	}
+
+	if len(q.Fields) > 0 {
+		values.Set(string(QueryKeyFields), EncodeFields(q.Fields))
+	}
+
+	if len(q.Includes) > 0 {
+		values.Set(string(QueryKeyInclude), EncodeIncludes(q.Includes))
+	}
+
+	if q.Search != "" {
+		values.Set(string(QueryKeySearch), q.Search)
+	}
+
+	return values
+}
+
+// eg: "filter", "price", "gt" -> "filter[price][gt]"
+func jsonapiKey(name QueryKey, members ...string) string {
+	var b strings.Builder
+	b.WriteString(string(name))
+	for _, m := range members {
+		b.WriteString(string(QueryParamSeparatorOperatorStart) + m + string(QueryParamSeparatorOperatorEnd))
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestParseJSONAPI(t *testing.T) {
+	type args struct {
+		rawQuery string
+		opts     Options
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     Query
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should parse filters, sort and page keys",
+			args: args{rawQuery: "filter[price][gt]=10&filter[tag][in]=a,b&sort=-createdAt,name&page[limit]=20&page[offset]=40&search=x"},
+			want: Query{
+				Pagination: Paginable{Limit: 20, Offset: 40},
+				Filters: []Filter{
+					{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+					{Field: "tag", Operation: FilterOperatorIn, Value: "a;b"},
+				},
+				Orders: []Order{{Field: "createdAt", Asc: false}, {Field: "name", Asc: true}},
+				Search: "x",
+			},
+		},
+		{
+			name: "should keep repeated keys and read a bare field as eq",
+			args: args{rawQuery: "filter[price][gt]=10&filter[price][lt]=20&filter[customer.name]=Smith, John&sort=name&sort=-price"},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+					{Field: "price", Operation: FilterOperatorLessThan, Value: "20"},
+					{Field: "customer.name", Operation: FilterOperatorEqual, Value: "Smith, John"},
+				},
+				Orders: []Order{{Field: "name", Asc: true}, {Field: "price", Asc: false}},
+			},
+		},
+		{
+			name: "should split list values on unescaped commas",
+			args: args{rawQuery: `filter[tag][in]=a\,b,"c;d"&filter[price][between]=1,2&filter[deletedAt][isNull]=`},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "tag", Operation: FilterOperatorIn, Value: `a,b;c\;d`},
+					{Field: "price", Operation: FilterOperatorBetween, Value: "1;2"},
+					{Field: "deletedAt", Operation: FilterOperatorIsNull},
+				},
+				Orders: []Order{},
+			},
+		},
+		{
+			name: "should read page number and size",
+			args: args{rawQuery: "page[number]=3&page[size]=25"},
+			want: Query{
+				Pagination: Paginable{Limit: 25, Offset: 50},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+		},
+		{
+			name: "should drop malformed keys when not strict",
+			args: args{rawQuery: "filter[price][gtt]=10&filter[price=1&page[foo]=1&filter[price][between]=1&sort=,name"},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{{Field: "name", Asc: true}},
+			},
+		},
+		{
+			name: "should report malformed keys and values when strict",
+			args: args{
+				rawQuery: "filter[price][gtt]=10&filter[price=1&page[foo]=1&filter[price][between]=1&filter[name][isNull]=a&sort=-,name&page[limit]=x",
+				opts:     Options{Strict: true},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{{Field: "name", Asc: true}},
+			},
+			wantErrs: []*SegmentError{
+				{Key: "filter[price][gtt]", Segment: "10", Err: ErrUnknownOperator},
+				{Key: "filter[price", Segment: "1", Err: ErrInvalidKey},
+				{Key: "page[foo]", Segment: "1", Err: ErrInvalidKey},
+				{Key: "filter[price][between]", Segment: "1", Err: ErrValueCount},
+				{Key: "filter[name][isNull]", Segment: "a", Err: ErrUnexpectedValue},
+				{Key: QueryKeyJSONAPISort, Segment: "-", Err: ErrInvalidField},
+				{Key: "page[limit]", Segment: "x", Err: ErrInvalidInteger},
+			},
+		},
+		{
+			name: "should always report what the schema rejects",
+			args: args{
+				rawQuery: "filter[price][gt]=10&filter[price][in]=1,2&filter[name][contains]=a&sort=-price,name",
+				opts:     Options{Schema: testSchema},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)},
+					{Field: "name", Operation: FilterOperatorContains, Value: "a", Typed: "a"},
+				},
+				Orders: []Order{{Field: "price", Asc: false}},
+			},
+			wantErrs: []*SegmentError{
+				{Key: "filter[price][in]", Segment: "1,2", Err: ErrOperatorNotAllowed},
+				{Key: QueryKeyJSONAPISort, Segment: "name", Position: 7, Err: ErrFieldNotSortable},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJSONAPI(splitQueryString(tt.args.rawQuery), tt.args.opts)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+
+			if tt.wantErrs == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			var errs ParseErrors
+			assert.True(t, errors.As(err, &errs), "got: %v", err)
+			assert.Equal(t, len(tt.wantErrs), len(errs), "got: %v", err)
+			for i := range errs {
+				if i >= len(tt.wantErrs) {
+					break
+				}
+				want := tt.wantErrs[i]
+				assert.Equal(t, want.Key, errs[i].Key)
+				assert.Equal(t, want.Segment, errs[i].Segment)
+				assert.Equal(t, want.Position, errs[i].Position)
+				assert.True(t, errors.Is(errs[i], want.Err), "got: %v, want: %v", errs[i], want.Err)
+			}
+		})
+	}
+}
+
+func TestParseJSONAPIFromFiber(t *testing.T) {
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI("/products?filter%5Bprice%5D%5Bgt%5D=10&filter[price][lt]=20&sort=-price&page[limit]=5")
+	ctx := app.AcquireCtx(fctx)
+	defer app.ReleaseCtx(ctx)
+
+	got, err := ParseJSONAPI(ctx, Options{Strict: true})
+	assert.Nil(t, err)
+	assert.Equal(t, []Filter{
+		{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+		{Field: "price", Operation: FilterOperatorLessThan, Value: "20"},
+	}, got.Filters)
+	assert.Equal(t, []Order{{Field: "price", Asc: false}}, got.Orders)
+	assert.Equal(t, 5, got.Pagination.Limit)
+
+	withSyntax, err := Parse(ctx, Options{Strict: true, Syntax: SyntaxJSONAPI})
+	assert.Nil(t, err)
+	assert.Equal(t, got, withSyntax)
+}
+
+func TestEncodeJSONAPI(t *testing.T) {
+	q := Query{
+		Pagination: Paginable{Limit: 20, Offset: 40},
+		Filters: []Filter{
+			{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+			{Field: "price", Operation: FilterOperatorLessThan, Value: "20"},
+			{Field: "tag", Operation: FilterOperatorIn, Value: JoinValues([]string{"a,b", `c"d`, "e;f"})},
+			{Field: "deletedAt", Operation: FilterOperatorIsNull},
+		},
+		Orders:   []Order{{Field: "createdAt", Asc: false}, {Field: "name", Asc: true}},
+		Search:   "x",
+		Fields:   []string{"name"},
+		Includes: Includes{{Relation: "customer"}},
+	}
+
+	values := EncodeJSONAPI(q)
+	assert.Equal(t, []string{"10"}, values["filter[price][gt]"])
+	assert.Equal(t, `a\,b,c\"d,e;f`, values.Get("filter[tag][in]"))
+	assert.Equal(t, "-createdAt,name", values.Get("sort"))
+
+	got, err := ParseValues(values, Options{Strict: true, Syntax: SyntaxJSONAPI})
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, q.Filters, got.Filters)
+	q.Filters = got.Filters
+	assert.Equal(t, q, got)
+}
@@ -0,0 +1,78 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Resolves a dotted path of json names, eg: "customer.name", on structs and string keyed maps,
+// following pointers and interfaces along the way
+func lookup(v reflect.Value, path string) (reflect.Value, bool) {
+	for _, name := range strings.Split(path, ".") {
+		v = indirect(v)
+
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := structFieldByJSONName(v, name)
+			if !ok {
+				return reflect.Value{}, false
+			}
+			v = field
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return reflect.Value{}, false
+			}
+			v = v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !v.IsValid() {
+				return reflect.Value{}, false
+			}
+		default:
+			return reflect.Value{}, false
+		}
+	}
+
+	return indirect(v), true
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func structFieldByJSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tagName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+
+		// same as SchemaFor, the fields of embedded structs are promoted even when their type is unexported
+		if (!sf.IsExported() && !(sf.Anonymous && tagName == "")) || tagName == "-" {
+			continue
+		}
+
+		if sf.Anonymous && tagName == "" {
+			if embedded := indirect(v.Field(i)); embedded.Kind() == reflect.Struct {
+				if field, ok := structFieldByJSONName(embedded, name); ok {
+					return field, true
+				}
+			}
+			continue
+		}
+
+		if tagName == "" {
+			tagName = sf.Name
+		}
+
+		if tagName == name {
+			return v.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
@@ -0,0 +1,297 @@
+// Package mongo translates parsed query filters, orders and pagination into MongoDB documents.
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrUnknownField        = errors.New("field has no document key")
+	ErrUnsupportedOperator = errors.New("operator is not supported")
+	ErrCountOnly           = errors.New("count only pagination has no find options")
+)
+
+var comparisonOperators = map[query.FilterOperator]string{
+	query.FilterOperatorEqual:              "$eq",
+	query.FilterOperatorNotEqual:           "$ne",
+	query.FilterOperatorLessThan:           "$lt",
+	query.FilterOperatorLessThanOrEqual:    "$lte",
+	query.FilterOperatorGreaterThan:        "$gt",
+	query.FilterOperatorGretherThanOrEqual: "$gte",
+}
+
+// Translates parsed queries into MongoDB documents. Only keys present on Fields are
+// ever written to the documents, so clients can't reach operators such as "$where".
+type Translator struct {
+	Fields map[string]string // maps the field names sent by clients to document keys, eg: "customerName" -> "customer.name"
+}
+
+// Translates the filters into a filter document, eg: {"price": {"$gt": 10}}.
+// Filters on the same key are merged, repeated operators are moved to an "$and".
+func (t Translator) Filter(filters []query.Filter) (bson.M, error) {
+	doc := bson.M{}
+	and := bson.A{}
+
+	for _, f := range filters {
+		key, err := t.key(f.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		ops, err := condition(f)
+		if err != nil {
+			return nil, err
+		}
+
+		conds, ok := doc[key].(bson.M)
+		if !ok {
+			conds = bson.M{}
+			doc[key] = conds
+		}
+
+		for _, op := range ops {
+			if _, exists := conds[op.Key]; exists {
+				and = append(and, bson.M{key: bson.M{op.Key: op.Value}})
+				continue
+			}
+
+			conds[op.Key] = op.Value
+		}
+	}
+
+	if len(and) > 0 {
+		doc["$and"] = and
+	}
+
+	return doc, nil
+}
+
+// Translates the expression into a filter document,
+// eg: {"$and": [{"$or": [{"status": {"$eq": "open"}}, ...]}, {"$nor": [{"deleted": {"$eq": true}}]}]}
+func (t Translator) FilterExpr(expr query.FilterExpr) (bson.M, error) {
+	if err := expr.Validate(); err != nil {
+		return nil, err
+	}
+	return t.filterExpr(expr)
+}
+
+func (t Translator) filterExpr(expr query.FilterExpr) (bson.M, error) {
+	switch expr.Kind {
+	case query.FilterExprLeaf:
+		return t.Filter([]query.Filter{*expr.Filter})
+	case query.FilterExprNot:
+		child, err := t.filterExpr(expr.Children[0])
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{child}}, nil
+	}
+
+	op := "$and"
+	if expr.Kind == query.FilterExprOr {
+		op = "$or"
+	}
+
+	children := bson.A{}
+	for _, c := range expr.Children {
+		child, err := t.filterExpr(c)
+		if err != nil {
+			return nil, err
+		}
+
+		children = append(children, child)
+	}
+
+	return bson.M{op: children}, nil
+}
+
+// The operators the filter translates to, eg: {"$gte": 10, "$lte": 20} for between
+func condition(f query.Filter) (bson.D, error) {
+	if op, ok := comparisonOperators[f.Operation]; ok {
+		return bson.D{{Key: op, Value: f.TypedValue()}}, nil
+	}
+
+	switch f.Operation {
+	case query.FilterOperatorIn:
+		return bson.D{{Key: "$in", Value: bson.A(f.TypedValues())}}, nil
+	case query.FilterOperatorNotIn:
+		return bson.D{{Key: "$nin", Value: bson.A(f.TypedValues())}}, nil
+	case query.FilterOperatorBetween:
+		values := f.TypedValues()
+		if len(values) != 2 {
+			return nil, fmt.Errorf("%w: %q takes 2", query.ErrValueCount, f.Operation)
+		}
+		return bson.D{{Key: "$gte", Value: values[0]}, {Key: "$lte", Value: values[1]}}, nil
+	case query.FilterOperatorIsNull:
+		return bson.D{{Key: "$eq", Value: nil}}, nil
+	case query.FilterOperatorNotNull:
+		return bson.D{{Key: "$ne", Value: nil}}, nil
+	case query.FilterOperatorStartsWith:
+		return regex("^"+regexp.QuoteMeta(f.Value), ""), nil
+	case query.FilterOperatorEndsWith:
+		return regex(regexp.QuoteMeta(f.Value)+"$", ""), nil
+	case query.FilterOperatorContains:
+		return regex(regexp.QuoteMeta(f.Value), ""), nil
+	case query.FilterOperatorEqualFold:
+		return regex("^"+regexp.QuoteMeta(f.Value)+"$", "i"), nil
+	case query.FilterOperatorContainsFold:
+		return regex(regexp.QuoteMeta(f.Value), "i"), nil
+	case query.FilterOperatorRegex:
+		return regex(f.Value, ""), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedOperator, f.Operation)
+}
+
+func regex(pattern, options string) bson.D {
+	return bson.D{{Key: "$regex", Value: primitive.Regex{Pattern: pattern, Options: options}}}
+}
+
+// Same as Filter, but also selects the documents after the pagination cursor, when it has one
+func (t Translator) PageFilter(filters []query.Filter, orders []query.Order, pagination query.Paginable) (bson.M, error) {
+	doc, err := t.Filter(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	if pagination.Cursor == nil {
+		return doc, nil
+	}
+
+	if !pagination.Cursor.Matches(orders) {
+		return nil, query.ErrCursorMismatch
+	}
+
+	keyset, err := t.Keyset(pagination.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc) == 0 {
+		return keyset, nil
+	}
+
+	return bson.M{"$and": bson.A{doc, keyset}}, nil
+}
+
+// Translates the cursor into the filter selecting the documents after it,
+// eg: {"$or": [{"price": {"$gt": 10}}, {"price": 10, "_id": {"$gt": 5}}]}
+func (t Translator) Keyset(cursor *query.Cursor) (bson.M, error) {
+	orders := cursor.FetchOrders()
+	if len(orders) == 0 || len(orders) != len(cursor.Values) {
+		return nil, query.ErrInvalidCursor
+	}
+
+	keys := make([]string, 0, len(orders))
+	for _, o := range orders {
+		key, err := t.key(o.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	alternatives := bson.A{}
+	for i, o := range orders {
+		alternative := bson.M{}
+		for j := 0; j < i; j++ {
+			alternative[keys[j]] = cursor.Values[j]
+		}
+
+		comparison := "$lt"
+		if o.Asc {
+			comparison = "$gt"
+		}
+		alternative[keys[i]] = bson.M{comparison: cursor.Values[i]}
+
+		alternatives = append(alternatives, alternative)
+	}
+
+	return bson.M{"$or": alternatives}, nil
+}
+
+// Translates the orders into a sort document, eg: {"price": 1, "name": -1}
+func (t Translator) Sort(orders []query.Order) (bson.D, error) {
+	sort := bson.D{}
+
+	for _, o := range orders {
+		key, err := t.key(o.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		direction := -1
+		if o.Asc {
+			direction = 1
+		}
+
+		sort = append(sort, bson.E{Key: key, Value: direction})
+	}
+
+	return sort, nil
+}
+
+// Translates the requested fields into a projection document, eg: {"_id": 1, "customer.name": 1},
+// to be set with FindOptions.SetProjection. Returns nil, meaning every field, when there are none.
+func (t Translator) Projection(fields []string) (bson.D, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	projection := bson.D{}
+	for _, f := range fields {
+		key, err := t.key(f)
+		if err != nil {
+			return nil, err
+		}
+
+		projection = append(projection, bson.E{Key: key, Value: 1})
+	}
+
+	return projection, nil
+}
+
+// Maps the pagination to skip and limit values
+func Pagination(pagination query.Paginable) (skip int64, limit int64) {
+	return int64(pagination.Offset), int64(pagination.Limit)
+}
+
+// Builds the options of a Find call with the sort, skip and limit of the query.
+// When the pagination has a cursor, the sort follows Cursor.FetchOrders and nothing is skipped.
+// Count only paginations are rejected with ErrCountOnly, as a zero limit means no limit to Find:
+// callers must use CountDocuments instead.
+func (t Translator) FindOptions(orders []query.Order, pagination query.Paginable) (*options.FindOptions, error) {
+	if pagination.CountOnly {
+		return nil, ErrCountOnly
+	}
+
+	skip, limit := Pagination(pagination)
+
+	if pagination.Cursor != nil {
+		orders = pagination.Cursor.FetchOrders()
+		skip = 0
+	}
+
+	sort, err := t.Sort(orders)
+	if err != nil {
+		return nil, err
+	}
+
+	return options.Find().SetSort(sort).SetSkip(skip).SetLimit(limit), nil
+}
+
+func (t Translator) key(field string) (string, error) {
+	key, ok := t.Fields[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownField, field)
+	}
+	return key, nil
+}
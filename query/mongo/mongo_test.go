@@ -0,0 +1,256 @@
+package mongo
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testTranslator = Translator{
+	Fields: map[string]string{
+		"price":        "price",
+		"name":         "name",
+		"tags":         "tags",
+		"customerName": "customer.name",
+	},
+}
+
+func TestTranslatorFilter(t *testing.T) {
+	type args struct {
+		filters []query.Filter
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    bson.M
+		wantErr error
+	}{
+		{
+			name: "should return empty document when there are no filters",
+			args: args{filters: []query.Filter{}},
+			want: bson.M{},
+		},
+		{
+			name: "should translate comparison operators",
+			args: args{filters: []query.Filter{
+				{Field: "price", Operation: query.FilterOperatorGretherThanOrEqual, Value: "10", Typed: int64(10)},
+				{Field: "price", Operation: query.FilterOperatorLessThan, Value: "20", Typed: int64(20)},
+				{Field: "name", Operation: query.FilterOperatorNotEqual, Value: "shoe"},
+				{Field: "customerName", Operation: query.FilterOperatorEqual, Value: "john"},
+			}},
+			want: bson.M{
+				"price":         bson.M{"$gte": int64(10), "$lt": int64(20)},
+				"name":          bson.M{"$ne": "shoe"},
+				"customer.name": bson.M{"$eq": "john"},
+			},
+		},
+		{
+			name: "should translate in operator",
+			args: args{filters: []query.Filter{
+				{Field: "tags", Operation: query.FilterOperatorIn, Value: "a;b"},
+			}},
+			want: bson.M{
+				"tags": bson.M{"$in": bson.A{"a", "b"}},
+			},
+		},
+		{
+			name: "should translate like operators to escaped regexes",
+			args: args{filters: []query.Filter{
+				{Field: "name", Operation: query.FilterOperatorStartsWith, Value: "a.b"},
+				{Field: "customerName", Operation: query.FilterOperatorEndsWith, Value: "(c)"},
+				{Field: "tags", Operation: query.FilterOperatorContains, Value: "d*"},
+			}},
+			want: bson.M{
+				"name":          bson.M{"$regex": primitive.Regex{Pattern: `^a\.b`}},
+				"customer.name": bson.M{"$regex": primitive.Regex{Pattern: `\(c\)$`}},
+				"tags":          bson.M{"$regex": primitive.Regex{Pattern: `d\*`}},
+			},
+		},
+		{
+			name: "should move repeated operators on the same key to an and",
+			args: args{filters: []query.Filter{
+				{Field: "name", Operation: query.FilterOperatorContains, Value: "a"},
+				{Field: "name", Operation: query.FilterOperatorContains, Value: "b"},
+			}},
+			want: bson.M{
+				"name": bson.M{"$regex": primitive.Regex{Pattern: "a"}},
+				"$and": bson.A{
+					bson.M{"name": bson.M{"$regex": primitive.Regex{Pattern: "b"}}},
+				},
+			},
+		},
+		{
+			name: "should translate range, exclusion, null and case insensitive operators",
+			args: args{filters: []query.Filter{
+				{Field: "price", Operation: query.FilterOperatorBetween, Value: "1;2", Typed: []int64{1, 2}},
+				{Field: "tags", Operation: query.FilterOperatorNotIn, Value: "a;b"},
+				{Field: "customerName", Operation: query.FilterOperatorIsNull},
+				{Field: "name", Operation: query.FilterOperatorEqualFold, Value: "a.b"},
+			}},
+			want: bson.M{
+				"price":         bson.M{"$gte": int64(1), "$lte": int64(2)},
+				"tags":          bson.M{"$nin": bson.A{"a", "b"}},
+				"customer.name": bson.M{"$eq": nil},
+				"name":          bson.M{"$regex": primitive.Regex{Pattern: `^a\.b$`, Options: "i"}},
+			},
+		},
+		{
+			name: "should translate not null, contains fold and regex operators",
+			args: args{filters: []query.Filter{
+				{Field: "customerName", Operation: query.FilterOperatorNotNull},
+				{Field: "name", Operation: query.FilterOperatorContainsFold, Value: "a*"},
+				{Field: "tags", Operation: query.FilterOperatorRegex, Value: "^a.*"},
+			}},
+			want: bson.M{
+				"customer.name": bson.M{"$ne": nil},
+				"name":          bson.M{"$regex": primitive.Regex{Pattern: `a\*`, Options: "i"}},
+				"tags":          bson.M{"$regex": primitive.Regex{Pattern: "^a.*"}},
+			},
+		},
+		{
+			name: "should return error when field has no document key",
+			args: args{filters: []query.Filter{
+				{Field: "$where", Operation: query.FilterOperatorEqual, Value: "1"},
+			}},
+			wantErr: ErrUnknownField,
+		},
+		{
+			name: "should return error when operator is not supported",
+			args: args{filters: []query.Filter{
+				{Field: "name", Operation: query.FilterOperator("like"), Value: "1"},
+			}},
+			wantErr: ErrUnsupportedOperator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testTranslator.Filter(tt.args.filters)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestTranslatorSort(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := testTranslator.Sort([]query.Order{{Field: "price", Asc: true}, {Field: "customerName", Asc: false}})
+	expect.Nil(err)
+	expect.Equal(bson.D{{Key: "price", Value: 1}, {Key: "customer.name", Value: -1}}, got)
+
+	_, err = testTranslator.Sort([]query.Order{{Field: "password", Asc: true}})
+	expect.True(errors.Is(err, ErrUnknownField))
+}
+
+func TestTranslatorFindOptions(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := testTranslator.FindOptions([]query.Order{{Field: "price", Asc: false}}, query.Paginable{Limit: 20, Offset: 40})
+	expect.Nil(err)
+	expect.Equal(bson.D{{Key: "price", Value: -1}}, got.Sort)
+	expect.Equal(int64(40), *got.Skip)
+	expect.Equal(int64(20), *got.Limit)
+
+	_, err = testTranslator.FindOptions(nil, query.Paginable{CountOnly: true})
+	expect.True(errors.Is(err, ErrCountOnly))
+}
+
+func TestTranslatorProjection(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := testTranslator.Projection(nil)
+	expect.Nil(err)
+	expect.Nil(got)
+
+	got, err = testTranslator.Projection([]string{"name", "customerName"})
+	expect.Nil(err)
+	expect.Equal(bson.D{{Key: "name", Value: 1}, {Key: "customer.name", Value: 1}}, got)
+
+	_, err = testTranslator.Projection([]string{"password"})
+	expect.True(errors.Is(err, ErrUnknownField))
+}
+
+func TestTranslatorPageFilter(t *testing.T) {
+	expect := assert.New(t)
+	orders := []query.Order{{Field: "price", Asc: true}, {Field: "name", Asc: false}}
+
+	got, err := testTranslator.PageFilter(nil, orders, query.Paginable{Limit: 10})
+	expect.Nil(err)
+	expect.Equal(bson.M{}, got)
+
+	cursor := &query.Cursor{Orders: orders, Values: []interface{}{int64(10), "a"}}
+	got, err = testTranslator.PageFilter([]query.Filter{{Field: "tags", Operation: query.FilterOperatorEqual, Value: "x"}}, orders, query.Paginable{Limit: 10, Cursor: cursor})
+	expect.Nil(err)
+	expect.Equal(bson.M{"$and": bson.A{
+		bson.M{"tags": bson.M{"$eq": "x"}},
+		bson.M{"$or": bson.A{
+			bson.M{"price": bson.M{"$gt": int64(10)}},
+			bson.M{"price": int64(10), "name": bson.M{"$lt": "a"}},
+		}},
+	}}, got)
+
+	cursor.Backward = true
+	got, err = testTranslator.PageFilter(nil, orders, query.Paginable{Limit: 10, Cursor: cursor})
+	expect.Nil(err)
+	expect.Equal(bson.M{"$or": bson.A{
+		bson.M{"price": bson.M{"$lt": int64(10)}},
+		bson.M{"price": int64(10), "name": bson.M{"$gt": "a"}},
+	}}, got)
+
+	opts, err := testTranslator.FindOptions(orders, query.Paginable{Limit: 10, Offset: 20, Cursor: cursor})
+	expect.Nil(err)
+	expect.Equal(bson.D{{Key: "price", Value: -1}, {Key: "name", Value: 1}}, opts.Sort)
+	expect.Equal(int64(0), *opts.Skip)
+
+	_, err = testTranslator.PageFilter(nil, orders[:1], query.Paginable{Limit: 10, Cursor: cursor})
+	expect.True(errors.Is(err, query.ErrCursorMismatch))
+}
+
+func TestTranslatorFilterExpr(t *testing.T) {
+	expect := assert.New(t)
+
+	expr := query.And(
+		query.Or(
+			query.Leaf(query.Filter{Field: "name", Operation: query.FilterOperatorEqual, Value: "a"}),
+			query.Leaf(query.Filter{Field: "price", Operation: query.FilterOperatorLessThan, Value: "5", Typed: int64(5)}),
+		),
+		query.Not(query.Leaf(query.Filter{Field: "tags", Operation: query.FilterOperatorIn, Value: "x;y"})),
+	)
+
+	got, err := testTranslator.FilterExpr(expr)
+	expect.Nil(err)
+	expect.Equal(bson.M{"$and": bson.A{
+		bson.M{"$or": bson.A{
+			bson.M{"name": bson.M{"$eq": "a"}},
+			bson.M{"price": bson.M{"$lt": int64(5)}},
+		}},
+		bson.M{"$nor": bson.A{
+			bson.M{"tags": bson.M{"$in": bson.A{"x", "y"}}},
+		}},
+	}}, got)
+
+	_, err = testTranslator.FilterExpr(query.Leaf(query.Filter{Field: "$where", Operation: query.FilterOperatorEqual, Value: "a"}))
+	expect.True(errors.Is(err, ErrUnknownField))
+
+	_, err = testTranslator.FilterExpr(query.And(query.FilterExpr{Kind: query.FilterExprLeaf}))
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.FilterExpr(query.FilterExpr{Kind: query.FilterExprNot})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.FilterExpr(query.And())
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, err = testTranslator.FilterExpr(query.Not(query.Or()))
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+}
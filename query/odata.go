@@ -0,0 +1,548 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrUnexpectedToken = errors.New("unexpected token")
+	ErrUnexpectedEnd   = errors.New("unexpected end of expression")
+)
+
+// Query keys of the OData subset, eg: "$filter=price gt 10 and startswith(name,'A')&$orderby=price desc&$top=10&$skip=20"
+const (
+	QueryKeyODataFilter  QueryKey = "$filter"
+	QueryKeyODataOrderBy QueryKey = "$orderby"
+	QueryKeyODataTop     QueryKey = "$top"
+	QueryKeyODataSkip    QueryKey = "$skip"
+	QueryKeyODataSearch  QueryKey = "$search"
+)
+
+// Separates the properties of a nested OData path, eg: "customer/name" -> "customer.name"
+const odataPathSeparator = "/"
+
+var odataComparisonOperators = map[string]FilterOperator{
+	"eq": FilterOperatorEqual,
+	"ne": FilterOperatorNotEqual,
+	"gt": FilterOperatorGreaterThan,
+	"ge": FilterOperatorGretherThanOrEqual,
+	"lt": FilterOperatorLessThan,
+	"le": FilterOperatorLessThanOrEqual,
+}
+
+var odataFunctions = map[string]FilterOperator{
+	"startswith": FilterOperatorStartsWith,
+	"endswith":   FilterOperatorEndsWith,
+	"contains":   FilterOperatorContains,
+}
+
+// Unquoted literals: numbers, booleans, dates, date times and guids
+var odataLiteralRegex = regexp.MustCompile(`^([+-]?\d+(\.\d+)?([eE][+-]?\d+)?|true|false|\d{4}-\d{2}-\d{2}(T[0-9:.]+(Z|[+-]\d{2}:\d{2})?)?|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// Same as Parse, but reads the OData subset keys instead: $filter becomes Query.Expr, and Query.Filters when
+// it only ANDs comparisons, $orderby becomes Query.Orders, $top and $skip become Query.Pagination and $search Query.Search.
+// Malformed $filter values are always reported, as with Options.Grouping.
+func ParseOData(ctx *fiber.Ctx, opts Options) (Query, error) {
+	return parseOData(queryArgsToValues(ctx), opts)
+}
+
+// Same as ParseOData, but framework agnostic
+func ParseODataValues(values url.Values, opts Options) (Query, error) {
+	return parseOData(values, opts)
+}
+
+func parseOData(values url.Values, opts Options) (Query, error) {
+	errs := ParseErrors{}
+
+	pagination, paginationErrs := parsePaginationValues(url.Values{
+		string(QueryKeyLimit):  values[string(QueryKeyODataTop)],
+		string(QueryKeyOffset): values[string(QueryKeyODataSkip)],
+	}, opts.Pagination, opts.Strict)
+	for _, err := range paginationErrs {
+		if err.Key == QueryKeyLimit {
+			err.Key = QueryKeyODataTop
+		} else {
+			err.Key = QueryKeyODataSkip
+		}
+		errs = append(errs, err)
+	}
+
+	filters := []Filter{}
+	var expr *FilterExpr
+	if value, ok := lastValue(values, QueryKeyODataFilter); ok {
+		var filterErrs ParseErrors
+		expr, filterErrs = parseODataFilter(value, opts.ExprLimits, opts.Schema)
+		if expr != nil && expr.IsConjunction() {
+			filters = expr.Filters()
+		}
+		errs = append(errs, filterErrs...)
+	}
+
+	orders := []Order{}
+	if value, ok := lastValue(values, QueryKeyODataOrderBy); ok {
+		var orderErrs ParseErrors
+		orders, orderErrs = parseODataOrderBy(value, opts.Schema, opts.Strict)
+		errs = append(errs, orderErrs...)
+	}
+
+	search, _ := lastValue(values, QueryKeyODataSearch)
+
+	return Query{
+		Pagination: pagination,
+		Filters:    filters,
+		Expr:       expr,
+		Orders:     orders,
+		Search:     strings.Trim(search, " "),
+	}, errs.orNil()
+}
+
+// Parses an OData $filter value into the same expression ParseFilterExpr builds for the native grammar,
+// eg: "(status eq 'open' or assignee eq 'me') and not deleted eq true".
+// Supports eq, ne, gt, ge, lt, le, in, and, or, not, groups, eq null, ne null and the startswith,
+// endswith and contains functions, with quoted strings, numbers, booleans, dates and guids.
+func ParseODataFilter(value string, limits ExprLimits) (*FilterExpr, error) {
+	expr, errs := parseODataFilter(value, limits, nil)
+	return expr, errs.orNil()
+}
+
+type odataTokenKind int
+
+const (
+	odataTokenWord odataTokenKind = iota // properties, operators and unquoted literals
+	odataTokenString
+	odataTokenGroupStart
+	odataTokenGroupEnd
+	odataTokenComma
+)
+
+type odataToken struct {
+	kind     odataTokenKind
+	text     string // unquoted text of strings
+	position int
+}
+
+func tokenizeOData(value string) ([]odataToken, *SegmentError) {
+	tokens := []odataToken{}
+
+	for i := 0; i < len(value); {
+		switch c := value[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, odataToken{kind: odataTokenGroupStart, text: "(", position: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, odataToken{kind: odataTokenGroupEnd, text: ")", position: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, odataToken{kind: odataTokenComma, text: ",", position: i})
+			i++
+		case c == '\'':
+			// quotes are escaped by doubling them, eg: 'O''Neil'
+			text := strings.Builder{}
+			j := i + 1
+			for ; ; j++ {
+				if j >= len(value) {
+					return nil, segment{text: value[i:], position: i}.error(QueryKeyODataFilter, ErrUnterminatedQuote)
+				}
+				if value[j] == '\'' {
+					if j+1 < len(value) && value[j+1] == '\'' {
+						text.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				text.WriteByte(value[j])
+			}
+			tokens = append(tokens, odataToken{kind: odataTokenString, text: text.String(), position: i})
+			i = j + 1
+		default:
+			j := i
+			for j < len(value) && !strings.ContainsRune(" \t(),'", rune(value[j])) {
+				j++
+			}
+			tokens = append(tokens, odataToken{kind: odataTokenWord, text: value[i:j], position: i})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+type odataParser struct {
+	value  string
+	tokens []odataToken
+	pos    int
+	limits ExprLimits
+	schema *Schema
+	nodes  int
+	errs   ParseErrors
+}
+
+func parseODataFilter(value string, limits ExprLimits, schema *Schema) (*FilterExpr, ParseErrors) {
+	tokens, err := tokenizeOData(value)
+	if err != nil {
+		return nil, ParseErrors{err}
+	}
+
+	if len(tokens) == 0 {
+		return nil, ParseErrors{}
+	}
+
+	p := &odataParser{value: value, tokens: tokens, limits: limits.withDefaults(), schema: schema, errs: ParseErrors{}}
+
+	expr, err := p.or(0)
+	if err == nil && p.pos < len(p.tokens) {
+		err = p.unexpected()
+	}
+
+	if err != nil {
+		return nil, append(p.errs, err)
+	}
+
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+
+	return &expr, p.errs
+}
+
+func (p *odataParser) error(position int, text string, err error) *SegmentError {
+	return segment{text: text, position: position}.error(QueryKeyODataFilter, err)
+}
+
+// Reports the current token, or the end of the value when there are no tokens left
+func (p *odataParser) unexpected() *SegmentError {
+	if p.pos >= len(p.tokens) {
+		return p.error(len(p.value), "", ErrUnexpectedEnd)
+	}
+
+	t := p.tokens[p.pos]
+	return p.error(t.position, t.text, ErrUnexpectedToken)
+}
+
+func (p *odataParser) peek(kind odataTokenKind, text string) bool {
+	if p.pos >= len(p.tokens) {
+		return false
+	}
+
+	t := p.tokens[p.pos]
+	return t.kind == kind && (text == "" || t.text == text)
+}
+
+// Consumes the next token when it is of the kind, reporting it otherwise
+func (p *odataParser) expect(kind odataTokenKind) (odataToken, *SegmentError) {
+	if !p.peek(kind, "") {
+		return odataToken{}, p.unexpected()
+	}
+
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *odataParser) node(position int) *SegmentError {
+	p.nodes++
+	if p.nodes > p.limits.MaxNodes {
+		return p.error(position, p.value[position:], ErrExprTooLarge)
+	}
+	return nil
+}
+
+func (p *odataParser) or(depth int) (FilterExpr, *SegmentError) {
+	return p.list(depth, "or", Or, p.and)
+}
+
+func (p *odataParser) and(depth int) (FilterExpr, *SegmentError) {
+	return p.list(depth, "and", And, p.unary)
+}
+
+// Parses operands separated by the keyword, combining them with combine when there is more than one
+func (p *odataParser) list(depth int, keyword string, combine func(...FilterExpr) FilterExpr, operand func(int) (FilterExpr, *SegmentError)) (FilterExpr, *SegmentError) {
+	position := len(p.value)
+	if p.pos < len(p.tokens) {
+		position = p.tokens[p.pos].position
+	}
+
+	children := []FilterExpr{}
+
+	for {
+		child, err := operand(depth)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, child)
+
+		if !p.peek(odataTokenWord, keyword) {
+			break
+		}
+		p.pos++
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	if err := p.node(position); err != nil {
+		return FilterExpr{}, err
+	}
+
+	return combine(children...), nil
+}
+
+func (p *odataParser) unary(depth int) (FilterExpr, *SegmentError) {
+	if p.pos >= len(p.tokens) {
+		return FilterExpr{}, p.unexpected()
+	}
+	start := p.tokens[p.pos]
+
+	switch {
+	case p.peek(odataTokenWord, "not"):
+		if depth+1 > p.limits.MaxDepth {
+			return FilterExpr{}, p.error(start.position, p.value[start.position:], ErrExprTooDeep)
+		}
+		if err := p.node(start.position); err != nil {
+			return FilterExpr{}, err
+		}
+
+		p.pos++
+		child, err := p.unary(depth + 1)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		return Not(child), nil
+	case p.peek(odataTokenGroupStart, ""):
+		if depth+1 > p.limits.MaxDepth {
+			return FilterExpr{}, p.error(start.position, p.value[start.position:], ErrExprTooDeep)
+		}
+
+		p.pos++
+		child, err := p.or(depth + 1)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+
+		if !p.peek(odataTokenGroupEnd, "") {
+			return FilterExpr{}, p.error(start.position, p.value[start.position:], ErrUnbalancedParens)
+		}
+		p.pos++
+		return child, nil
+	}
+
+	return p.leaf()
+}
+
+// Parses a comparison, eg: "price gt 10", "status in ('a','b')", or a function call, eg: "contains(name,'a')"
+func (p *odataParser) leaf() (FilterExpr, *SegmentError) {
+	start := p.tokens[p.pos]
+	if err := p.node(start.position); err != nil {
+		return FilterExpr{}, err
+	}
+
+	var f Filter
+	var err *SegmentError
+
+	if op, ok := odataFunctions[start.text]; ok && start.kind == odataTokenWord && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].kind == odataTokenGroupStart {
+		f, err = p.function(op)
+	} else {
+		f, err = p.comparison()
+	}
+	if err != nil {
+		return FilterExpr{}, err
+	}
+
+	end := len(p.value)
+	if p.pos < len(p.tokens) {
+		end = p.tokens[p.pos].position
+	}
+	text := strings.TrimRight(p.value[start.position:end], " \t")
+
+	if p.schema != nil {
+		coerced, coerceErr := p.schema.CoerceFilter(f)
+		if coerceErr != nil {
+			// keeps parsing to report every filter the schema rejects
+			p.errs = append(p.errs, p.error(start.position, text, coerceErr))
+			return Leaf(Filter{}), nil
+		}
+		f = coerced
+	}
+
+	return Leaf(f), nil
+}
+
+// eg: "startswith(name,'A')"
+func (p *odataParser) function(op FilterOperator) (Filter, *SegmentError) {
+	p.pos += 2 // name and "("
+
+	field, err := p.property()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if _, err := p.expect(odataTokenComma); err != nil {
+		return Filter{}, err
+	}
+
+	value, err := p.expect(odataTokenString)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if _, err := p.expect(odataTokenGroupEnd); err != nil {
+		return Filter{}, err
+	}
+
+	return Filter{Field: field, Operation: op, Value: value.text}, nil
+}
+
+// eg: "price gt 10", "deletedAt eq null" or "status in ('open','closed')"
+func (p *odataParser) comparison() (Filter, *SegmentError) {
+	field, err := p.property()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	operator, err := p.expect(odataTokenWord)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	if operator.text == "in" {
+		return p.in(field)
+	}
+
+	op, ok := odataComparisonOperators[operator.text]
+	if !ok {
+		return Filter{}, p.error(operator.position, operator.text, fmt.Errorf("%w: %q", ErrUnknownOperator, operator.text))
+	}
+
+	if p.peek(odataTokenWord, "null") {
+		p.pos++
+		switch op {
+		case FilterOperatorEqual:
+			return Filter{Field: field, Operation: FilterOperatorIsNull}, nil
+		case FilterOperatorNotEqual:
+			return Filter{Field: field, Operation: FilterOperatorNotNull}, nil
+		}
+		return Filter{}, p.error(operator.position, operator.text+" null", ErrUnknownOperator)
+	}
+
+	value, err := p.literal()
+	if err != nil {
+		return Filter{}, err
+	}
+
+	return Filter{Field: field, Operation: op, Value: value}, nil
+}
+
+// eg: "('open','closed')" after "status in"
+func (p *odataParser) in(field string) (Filter, *SegmentError) {
+	if _, err := p.expect(odataTokenGroupStart); err != nil {
+		return Filter{}, err
+	}
+
+	values := []string{}
+	for {
+		value, err := p.literal()
+		if err != nil {
+			return Filter{}, err
+		}
+		values = append(values, value)
+
+		if !p.peek(odataTokenComma, "") {
+			break
+		}
+		p.pos++
+	}
+
+	if _, err := p.expect(odataTokenGroupEnd); err != nil {
+		return Filter{}, err
+	}
+
+	return Filter{Field: field, Operation: FilterOperatorIn, Value: JoinValues(values)}, nil
+}
+
+// Returns the property path with nested properties separated by FieldPathSeparator, eg: "customer/name" -> "customer.name"
+func (p *odataParser) property() (string, *SegmentError) {
+	t, err := p.expect(odataTokenWord)
+	if err != nil {
+		return "", err
+	}
+
+	path := strings.ReplaceAll(t.text, odataPathSeparator, FieldPathSeparator)
+	for _, name := range strings.Split(path, FieldPathSeparator) {
+		if !hasALetter(name) {
+			return "", p.error(t.position, t.text, ErrInvalidField)
+		}
+	}
+
+	return path, nil
+}
+
+func (p *odataParser) literal() (string, *SegmentError) {
+	if p.peek(odataTokenString, "") {
+		p.pos++
+		return p.tokens[p.pos-1].text, nil
+	}
+
+	t, err := p.expect(odataTokenWord)
+	if err != nil {
+		return "", err
+	}
+
+	if !odataLiteralRegex.MatchString(t.text) {
+		return "", p.error(t.position, t.text, ErrUnexpectedToken)
+	}
+
+	return t.text, nil
+}
+
+// Parses an OData $orderby value, eg: "price desc,customer/name" -> price descending and customer.name ascending
+func parseODataOrderBy(value string, schema *Schema, strict bool) ([]Order, ParseErrors) {
+	orders := []Order{}
+	errs := ParseErrors{}
+
+	for _, segment := range splitSegments(value, QueryParamSeparatorMap) {
+		o, err := parseODataOrder(segment.text)
+		if err != nil {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyODataOrderBy, err))
+			}
+			continue
+		}
+
+		if schema != nil {
+			if err := schema.ValidateOrder(o); err != nil {
+				errs = append(errs, segment.error(QueryKeyODataOrderBy, err))
+				continue
+			}
+		}
+
+		orders = append(orders, o)
+	}
+
+	return orders, errs
+}
+
+func parseODataOrder(value string) (Order, error) {
+	parts := strings.Fields(value)
+	if len(parts) == 0 || len(parts) > 2 {
+		return Order{}, ErrInvalidField
+	}
+
+	direction := "asc"
+	if len(parts) == 2 {
+		direction = parts[1]
+		if direction != "asc" && direction != "desc" {
+			return Order{}, fmt.Errorf("%w: %q", ErrInvalidDirection, direction)
+		}
+	}
+
+	return getOrder(strings.ReplaceAll(parts[0], odataPathSeparator, FieldPathSeparator), direction)
+}
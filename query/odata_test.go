@@ -0,0 +1,127 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseODataFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		native   string // same expression on the native grammar
+		wantErrs []*SegmentError
+	}{
+		{
+			name:   "should parse comparisons",
+			value:  "price gt 10 and price le 20.5 and name eq 'Smith, John' and createdAt ge 2022-01-01T10:00:00Z",
+			native: `price[gt]10,price[le]20.5,name[eq]Smith\, John,createdAt[ge]2022-01-01T10:00:00Z`,
+		},
+		{
+			name:   "should parse or, not and groups with and binding tighter",
+			value:  "(status eq 'open' or assignee eq 'me') and not deleted eq true or id eq 7",
+			native: "(status[eq]open|assignee[eq]me),!deleted[eq]true|id[eq]7",
+		},
+		{
+			name:   "should parse functions, in and null comparisons",
+			value:  "startswith(name,'A') and contains(customer/name, 'O''Neil') and status in ('a;b', 'c') and deletedAt eq null and endswith(name,'z') and email ne null",
+			native: `name[startsWith]A,customer.name[contains]O'Neil,status[in]a\;b;c,deletedAt[isNull],name[endsWith]z,email[notNull]`,
+		},
+		{
+			name:  "should report unknown operators",
+			value: "price gtt 10",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "gtt", Position: 6, Err: ErrUnknownOperator},
+			},
+		},
+		{
+			name:  "should report comparisons between properties",
+			value: "price gt cost",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "cost", Position: 9, Err: ErrUnexpectedToken},
+			},
+		},
+		{
+			name:  "should report unterminated strings",
+			value: "name eq 'abc",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "'abc", Position: 8, Err: ErrUnterminatedQuote},
+			},
+		},
+		{
+			name:  "should report unbalanced parens",
+			value: "(price gt 10",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "(price gt 10", Position: 0, Err: ErrUnbalancedParens},
+			},
+		},
+		{
+			name:  "should report incomplete expressions",
+			value: "price gt 10 and",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "", Position: 15, Err: ErrUnexpectedEnd},
+			},
+		},
+		{
+			name:  "should report too deep expressions",
+			value: "not not not not not not a eq 1",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyODataFilter, Segment: "not a eq 1", Position: 20, Err: ErrExprTooDeep},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseODataFilter(tt.value, ExprLimits{})
+			assertSegmentErrors(t, tt.wantErrs, err)
+
+			if tt.native != "" {
+				want, err := ParseFilterExpr(tt.native, ExprLimits{})
+				assert.Nil(t, err)
+				assert.True(t, reflect.DeepEqual(want, got), "got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseODataValues(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := ParseODataValues(url.Values{
+		"$filter":  {"price gt 10 and name eq 'a'"},
+		"$orderby": {"price desc, customer/name"},
+		"$top":     {"5"},
+		"$skip":    {"10"},
+		"$search":  {"boots"},
+	}, Options{})
+	expect.Nil(err)
+	expect.Equal(Paginable{Limit: 5, Offset: 10}, got.Pagination)
+	expect.Equal([]Filter{
+		{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+		{Field: "name", Operation: FilterOperatorEqual, Value: "a"},
+	}, got.Filters)
+	expect.NotNil(got.Expr)
+	expect.Equal([]Order{{Field: "price", Asc: false}, {Field: "customer.name", Asc: true}}, got.Orders)
+	expect.Equal("boots", got.Search)
+
+	got, err = ParseODataValues(url.Values{"$filter": {"price gt 10 or price lt 5"}}, Options{})
+	expect.Nil(err)
+	expect.Equal([]Filter{}, got.Filters, "filters are only set when the expression only ANDs comparisons")
+
+	_, err = ParseODataValues(url.Values{
+		"$filter":  {"price gt 10 and name eq 'a'"},
+		"$orderby": {"name desc"},
+		"$top":     {"x"},
+	}, Options{Strict: true, Schema: testSchema})
+	var errs ParseErrors
+	expect.True(errors.As(err, &errs))
+	expect.Len(errs, 3)
+	expect.Equal(QueryKeyODataTop, errs[0].Key)
+	expect.True(errors.Is(errs[1], ErrOperatorNotAllowed), "name only accepts contains")
+	expect.True(errors.Is(errs[2], ErrFieldNotSortable))
+}
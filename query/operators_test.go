@@ -0,0 +1,129 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterOperatorArity(t *testing.T) {
+	tests := []struct {
+		operator FilterOperator
+		want     Arity
+	}{
+		{operator: FilterOperatorEqual, want: ArityOne},
+		{operator: FilterOperatorEqualFold, want: ArityOne},
+		{operator: FilterOperatorContainsFold, want: ArityOne},
+		{operator: FilterOperatorRegex, want: ArityOne},
+		{operator: FilterOperatorIsNull, want: ArityNone},
+		{operator: FilterOperatorNotNull, want: ArityNone},
+		{operator: FilterOperatorBetween, want: ArityTwo},
+		{operator: FilterOperatorIn, want: ArityMany},
+		{operator: FilterOperatorNotIn, want: ArityMany},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.operator), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.operator.Arity())
+		})
+	}
+}
+
+func TestGetFilterFromQueryWithNewOperators(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []Filter
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should parse operators that take no value",
+			args: args{queryParams: url.Values{"filters": {"a[isNull],b[notNull]"}}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorIsNull},
+				{Field: "b", Operation: FilterOperatorNotNull},
+			},
+		},
+		{
+			name: "should parse list and case insensitive operators",
+			args: args{queryParams: url.Values{"filters": {"a[between]1;2,b[nin]x;y,c[ieq]Foo,d[icontains]Bar"}}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorBetween, Value: "1;2"},
+				{Field: "b", Operation: FilterOperatorNotIn, Value: "x;y"},
+				{Field: "c", Operation: FilterOperatorEqualFold, Value: "Foo"},
+				{Field: "d", Operation: FilterOperatorContainsFold, Value: "Bar"},
+			},
+		},
+		{
+			name: "should report wrong amount of values",
+			args: args{queryParams: url.Values{"filters": {"a[isNull]x,b[between]1,c[between]1;2;3"}}},
+			want: []Filter{},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "a[isNull]x", Position: 0, Err: ErrUnexpectedValue},
+				{Key: QueryKeyFilters, Segment: "b[between]1", Position: 11, Err: ErrValueCount},
+				{Key: QueryKeyFilters, Segment: "c[between]1;2;3", Position: 23, Err: ErrValueCount},
+			},
+		},
+		{
+			name: "should report regex when parsed without a schema",
+			args: args{queryParams: url.Values{"filters": {"a[regex]^x"}}},
+			want: []Filter{},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "a[regex]^x", Position: 0, Err: ErrUnknownOperator},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getFilterFromQueryStrict(tt.args.queryParams)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, err)
+		})
+	}
+}
+
+func TestSchemaWithNewOperators(t *testing.T) {
+	expect := assert.New(t)
+	schema := &Schema{
+		Fields: map[string]Field{
+			"price":     {Type: FieldTypeInt, Operators: []FilterOperator{FilterOperatorBetween, FilterOperatorIsNull, FilterOperatorEqualFold}},
+			"name":      {Operators: []FilterOperator{FilterOperatorRegex}},
+			"createdAt": {Operators: []FilterOperator{FilterOperatorEqual}},
+		},
+	}
+
+	got, err := getFilterFromQueryWithSchema(url.Values{"filters": {"price[between]10;20,price[isNull],name[regex]^a.*"}}, schema)
+	expect.Nil(err)
+	expect.Equal([]Filter{
+		{Field: "price", Operation: FilterOperatorBetween, Value: "10;20", Typed: []int64{10, 20}},
+		{Field: "price", Operation: FilterOperatorIsNull},
+		{Field: "name", Operation: FilterOperatorRegex, Value: "^a.*", Typed: "^a.*"},
+	}, got)
+
+	_, err = getFilterFromQueryWithSchema(url.Values{"filters": {"price[ieq]10"}}, schema)
+	expect.True(errors.Is(err, ErrOperatorUnsupported), "ieq is only supported by strings")
+
+	_, err = getFilterFromQueryWithSchema(url.Values{"filters": {"createdAt[regex]x"}}, schema)
+	expect.True(errors.Is(err, ErrOperatorNotAllowed), "regex must be listed on the field operators")
+}
+
+func TestEncodeFiltersWithNewOperators(t *testing.T) {
+	filters := []Filter{
+		{Field: "a", Operation: FilterOperatorIsNull},
+		{Field: "b", Operation: FilterOperatorBetween, Value: JoinValues([]string{"1", "2"})},
+	}
+
+	encoded := EncodeFilters(filters)
+	assert.Equal(t, "a[isNull],b[between]1;2", encoded)
+
+	got, err := getFilterFromQueryStrict(url.Values{"filters": {encoded}})
+	assert.Nil(t, err)
+	assert.Equal(t, filters, got)
+}
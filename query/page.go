@@ -0,0 +1,210 @@
+package query
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Response envelope of a list endpoint, paginated either by offset or by cursor
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`               // always 0 when paginating by cursor
+	NextCursor string `json:"nextCursor,omitempty"` // only set when paginating by cursor and there is a next page
+	PrevCursor string `json:"prevCursor,omitempty"` // only set when paginating by cursor and there is a previous page
+	Total      *int   `json:"total,omitempty"`      // amount of records matching the query, unknown when paginating by cursor
+	HasMore    bool   `json:"hasMore"`              // if true, there is a next page
+}
+
+// Page of an offset paginated query, eg: NewPage(rows, q.Pagination, total)
+func NewPage[T any](items []T, pagination Paginable, total int) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	return Page[T]{
+		Items:   items,
+		Limit:   pagination.Limit,
+		Offset:  pagination.Offset,
+		Total:   &total,
+		HasMore: pagination.HasNext(total),
+	}
+}
+
+// Page of a cursor paginated query, where orders are the ones the cursors are built for, see WithTiebreaker.
+// Items must be fetched with Cursor.FetchOrders and one row more than the limit, which is only used
+// to tell whether there are more rows. Rows of a backward page are reversed back.
+func NewCursorPage[T any](items []T, pagination Paginable, orders []Order, codec CursorCodec) (Page[T], error) {
+	more := len(items) > pagination.Limit
+	if more {
+		items = items[:pagination.Limit]
+	}
+
+	page := Page[T]{Items: make([]T, len(items)), Limit: pagination.Limit}
+	copy(page.Items, items)
+
+	backward := pagination.Cursor != nil && pagination.Cursor.Backward
+	if backward {
+		for i, j := 0, len(page.Items)-1; i < j; i, j = i+1, j-1 {
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+	}
+
+	hasNext, hasPrev := more, pagination.Cursor != nil
+	if backward {
+		hasNext, hasPrev = true, more
+	}
+
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	if hasNext {
+		cursor, err := NextCursor(page.Items[len(page.Items)-1], orders)
+		if err != nil {
+			return Page[T]{}, err
+		}
+
+		page.NextCursor, err = codec.Encode(cursor)
+		if err != nil {
+			return Page[T]{}, err
+		}
+		page.HasMore = true
+	}
+
+	if hasPrev {
+		cursor, err := PrevCursor(page.Items[0], orders)
+		if err != nil {
+			return Page[T]{}, err
+		}
+
+		page.PrevCursor, err = codec.Encode(cursor)
+		if err != nil {
+			return Page[T]{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// Sends the page as JSON, setting the X-Total-Count header when the total is known and the
+// Link header (RFC 8288) to the first, prev, next and last pages that exist, eg:
+// `<https://api.com/products?limit=10&offset=10&order=price:asc>; rel="next"`.
+// Links keep the filters, orders and search of q, the query the page was fetched with.
+func SendPage[T any](c *fiber.Ctx, page Page[T], q Query) error {
+	return SendPageWithOptions(c, page, q, Options{})
+}
+
+// Same as SendPage, but links are encoded in opts.Syntax, the one q was parsed with, so the endpoint
+// parses them back. Fails with ErrNotExpressible when q can't be encoded in it, eg: OR filters in JSON:API.
+// OData pages are sent without links, as there is no OData encoder.
+func SendPageWithOptions[T any](c *fiber.Ctx, page Page[T], q Query, opts Options) error {
+	links, err := pageLinks(c.BaseURL()+c.Path(), page, q, opts.Syntax)
+	if err != nil {
+		return err
+	}
+
+	if page.Total != nil {
+		c.Set("X-Total-Count", strconv.Itoa(*page.Total))
+	}
+
+	if links != "" {
+		c.Set(fiber.HeaderLink, links)
+	}
+
+	return c.JSON(page)
+}
+
+// Encodes the query in the syntax, along with the cursor when it is not empty
+func encodeLink(q Query, syntax Syntax, cursor string) (url.Values, error) {
+	if syntax == SyntaxJSONAPI {
+		if q.Expr != nil && !q.Expr.IsConjunction() {
+			return nil, fmt.Errorf("%w: filters expression", ErrNotExpressible)
+		}
+
+		values := EncodeJSONAPI(q)
+		if cursor != "" {
+			values.Set(jsonapiKey(QueryKeyJSONAPIPage, "cursor"), cursor)
+		}
+		return values, nil
+	}
+
+	values := Encode(q)
+
+	if syntax == SyntaxRSQL && (q.Expr != nil || len(q.Filters) > 0) {
+		filters, err := EncodeRSQL(q.FilterExpr())
+		if err != nil {
+			return nil, err
+		}
+		values.Set(string(QueryKeyFilters), filters)
+	}
+
+	if cursor != "" {
+		values.Set(string(QueryKeyCursor), cursor)
+	}
+	return values, nil
+}
+
+// Builds the links of the page, failing with the first query that can't be encoded in the syntax
+func pageLinks[T any](base string, page Page[T], q Query, syntax Syntax) (string, error) {
+	if syntax == SyntaxOData {
+		return "", nil
+	}
+
+	links := []string{}
+	var err error
+
+	link := func(rel string, p Paginable, cursor string) {
+		if err != nil {
+			return
+		}
+
+		q.Pagination = p
+		var values url.Values
+		values, err = encodeLink(q, syntax, cursor)
+		links = append(links, "<"+base+"?"+values.Encode()+`>; rel="`+rel+`"`)
+	}
+
+	offsetLink := func(rel string, offset int) {
+		link(rel, Paginable{Limit: page.Limit, Offset: offset}, "")
+	}
+
+	// the empty cursor keeps the offset out of the link
+	cursorLink := func(rel string, cursor string) {
+		link(rel, Paginable{Limit: page.Limit, Cursor: &Cursor{}}, cursor)
+	}
+
+	if page.Total == nil {
+		if page.PrevCursor != "" {
+			cursorLink("first", "")
+			cursorLink("prev", page.PrevCursor)
+		}
+		if page.NextCursor != "" {
+			cursorLink("next", page.NextCursor)
+		}
+	} else {
+		if page.Offset > 0 {
+			prev := page.Offset - page.Limit
+			if prev < 0 {
+				prev = 0
+			}
+
+			offsetLink("first", 0)
+			offsetLink("prev", prev)
+		}
+
+		if page.HasMore {
+			offsetLink("next", page.Offset+page.Limit)
+			offsetLink("last", (Paginable{Limit: page.Limit}.TotalPages(*page.Total)-1)*page.Limit)
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(links, ", "), nil
+}
@@ -0,0 +1,183 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type pageTestRow struct {
+	ID int `json:"id"`
+}
+
+func TestNewPage(t *testing.T) {
+	page := NewPage([]pageTestRow(nil), Paginable{Limit: 10, Offset: 10}, 21)
+
+	body, err := json.Marshal(page)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"items": [], "limit": 10, "offset": 10, "total": 21, "hasMore": true}`, string(body))
+}
+
+func TestNewCursorPage(t *testing.T) {
+	expect := assert.New(t)
+	codec := CursorCodec{Secret: []byte("secret")}
+	orders := []Order{{Field: "id", Asc: true}}
+	rows := []pageTestRow{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	page, err := NewCursorPage(rows, Paginable{Limit: 2}, orders, codec)
+	expect.Nil(err)
+	expect.Equal([]pageTestRow{{ID: 1}, {ID: 2}}, page.Items)
+	expect.True(page.HasMore)
+	expect.Empty(page.PrevCursor, "first page has no previous page")
+	next, err := codec.Decode(page.NextCursor)
+	expect.Nil(err)
+	expect.Equal(Cursor{Orders: orders, Values: []interface{}{int64(2)}}, next)
+
+	page, err = NewCursorPage(rows[2:], Paginable{Limit: 2, Cursor: &next}, orders, codec)
+	expect.Nil(err)
+	expect.Equal([]pageTestRow{{ID: 3}}, page.Items)
+	expect.False(page.HasMore)
+	expect.Empty(page.NextCursor)
+	prev, err := codec.Decode(page.PrevCursor)
+	expect.Nil(err)
+	expect.Equal(Cursor{Orders: orders, Values: []interface{}{int64(3)}, Backward: true}, prev)
+
+	// fetched with the reversed orders: 2, 1
+	page, err = NewCursorPage([]pageTestRow{{ID: 2}, {ID: 1}}, Paginable{Limit: 2, Cursor: &prev}, orders, codec)
+	expect.Nil(err)
+	expect.Equal([]pageTestRow{{ID: 1}, {ID: 2}}, page.Items)
+	expect.True(page.HasMore)
+	expect.NotEmpty(page.NextCursor)
+	expect.Empty(page.PrevCursor, "backward page without an extra row is the first page")
+}
+
+func TestPageLinks(t *testing.T) {
+	q := Query{
+		Pagination: Paginable{Limit: 10, Offset: 15},
+		Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "b"}},
+		Orders:     []Order{{Field: "c", Asc: true}},
+	}
+
+	tests := []struct {
+		name string
+		page Page[pageTestRow]
+		want string
+	}{
+		{
+			name: "should link to every page",
+			page: NewPage([]pageTestRow{}, q.Pagination, 50),
+			want: `<https://api.com/products?filters=a%5Beq%5Db&limit=10&offset=0&order=c%3Aasc>; rel="first", ` +
+				`<https://api.com/products?filters=a%5Beq%5Db&limit=10&offset=5&order=c%3Aasc>; rel="prev", ` +
+				`<https://api.com/products?filters=a%5Beq%5Db&limit=10&offset=25&order=c%3Aasc>; rel="next", ` +
+				`<https://api.com/products?filters=a%5Beq%5Db&limit=10&offset=40&order=c%3Aasc>; rel="last"`,
+		},
+		{
+			name: "should not link before the first page nor after the last",
+			page: NewPage([]pageTestRow{}, Paginable{Limit: 10}, 5),
+			want: "",
+		},
+		{
+			name: "should link by cursor",
+			page: Page[pageTestRow]{Limit: 10, NextCursor: "next", PrevCursor: "prev"},
+			want: `<https://api.com/products?filters=a%5Beq%5Db&limit=10&order=c%3Aasc>; rel="first", ` +
+				`<https://api.com/products?cursor=prev&filters=a%5Beq%5Db&limit=10&order=c%3Aasc>; rel="prev", ` +
+				`<https://api.com/products?cursor=next&filters=a%5Beq%5Db&limit=10&order=c%3Aasc>; rel="next"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pageLinks("https://api.com/products", tt.page, q, SyntaxNative)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// Every link is parsed back by the endpoint into the same query, at the linked page
+func TestPageLinksRoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		opts  Options
+	}{
+		{
+			name:  "should link in the native syntax",
+			query: `filters=(name[eq]Smith\, John|price[lt]5),tags[in]a\;b;c&order=price:desc&search=x`,
+			opts:  Options{Strict: true, Grouping: true},
+		},
+		{
+			name:  "should link in RSQL",
+			query: `filters=(name=="Smith, John",price=lt=5);tags=in=("a;b",c)&order=price:desc&search=x`,
+			opts:  Options{Strict: true, Syntax: SyntaxRSQL},
+		},
+		{
+			name:  "should link in JSON:API",
+			query: `filter[name][eq]=Smith, John&filter[tags][in]=a\,b,c&sort=-price&search=x`,
+			opts:  Options{Strict: true, Syntax: SyntaxJSONAPI},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := ParseValues(ParseQueryString(tt.query), tt.opts)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			links, err := pageLinks("https://api.com/products", NewPage([]pageTestRow{}, Paginable{Limit: 10, Offset: 10}, 50), q, tt.opts.Syntax)
+			assert.Nil(t, err)
+
+			offsets := []int{}
+			for _, link := range strings.Split(links, ", ") {
+				raw, _, _ := strings.Cut(strings.TrimPrefix(link, "<https://api.com/products?"), ">")
+
+				got, err := ParseValues(ParseQueryString(raw), tt.opts)
+				assert.Nil(t, err, "link: %s", link)
+				assert.Equal(t, q.FilterExpr(), got.FilterExpr(), "link: %s", link)
+				assert.Equal(t, q.Orders, got.Orders, "link: %s", link)
+				assert.Equal(t, q.Search, got.Search, "link: %s", link)
+				offsets = append(offsets, got.Pagination.Offset)
+			}
+			assert.Equal(t, []int{0, 0, 20, 40}, offsets)
+		})
+	}
+
+	links, err := pageLinks("https://api.com/products", NewPage([]pageTestRow{}, Paginable{Limit: 10, Offset: 10}, 50), Query{}, SyntaxOData)
+	assert.Nil(t, err)
+	assert.Empty(t, links, "should not link in OData")
+
+	or := Or(Leaf(Filter{Field: "a", Operation: FilterOperatorEqual, Value: "1"}), Leaf(Filter{Field: "b", Operation: FilterOperatorEqual, Value: "2"}))
+	_, err = pageLinks("https://api.com/products", NewPage([]pageTestRow{}, Paginable{Limit: 10}, 50), Query{Expr: &or}, SyntaxJSONAPI)
+	assert.True(t, errors.Is(err, ErrNotExpressible))
+
+	links, err = pageLinks("https://api.com/products", Page[pageTestRow]{Limit: 10, NextCursor: "next"}, Query{}, SyntaxJSONAPI)
+	assert.Nil(t, err)
+	assert.Equal(t, `<https://api.com/products?page%5Bcursor%5D=next&page%5Blimit%5D=10>; rel="next"`, links)
+}
+
+func TestSendPage(t *testing.T) {
+	expect := assert.New(t)
+
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI("http://api.com/products?limit=1&search=x")
+	ctx := app.AcquireCtx(fctx)
+	defer app.ReleaseCtx(ctx)
+
+	q, err := Parse(ctx, Options{})
+	expect.Nil(err)
+
+	err = SendPage(ctx, NewPage([]pageTestRow{{ID: 1}}, q.Pagination, 2), q)
+	expect.Nil(err)
+
+	expect.Equal("2", string(fctx.Response.Header.Peek("X-Total-Count")))
+	expect.Equal(`<http://api.com/products?limit=1&offset=1&search=x>; rel="next", <http://api.com/products?limit=1&offset=1&search=x>; rel="last"`,
+		string(fctx.Response.Header.Peek(fiber.HeaderLink)))
+	expect.JSONEq(`{"items": [{"id": 1}], "limit": 1, "offset": 0, "total": 2, "hasMore": true}`, string(fctx.Response.Body()))
+}
@@ -0,0 +1,210 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrInvalidInteger  = errors.New("not a non negative integer")
+	ErrLimitTooLarge   = errors.New("limit is above the maximum")
+	ErrOffsetTooLarge  = errors.New("offset is above the maximum")
+	ErrInvalidPage     = errors.New("pages start at 1")
+	ErrMixedPagination = errors.New("limit and offset can not be mixed with page and pageSize")
+)
+
+type PaginationOptions struct {
+	DefaultLimit   int  // limit used when the query has none, defaults to 10
+	MaxLimit       int  // limits above it are clamped to it, no maximum when zero
+	RejectAboveMax bool // if true, limits above MaxLimit are also reported with ErrLimitTooLarge
+	MaxOffset      int  // offsets above it are reported and replaced by 0, no maximum when zero
+	AllowCountOnly bool // if true, "limit=0" sets Paginable.CountOnly, else it means the default limit
+
+	// Which style is read when the query has both limit/offset and page/pageSize keys
+	Precedence PaginationPrecedence
+}
+
+type PaginationPrecedence int
+
+const (
+	PaginationPrecedenceOffset PaginationPrecedence = iota // limit and offset win, page and pageSize are ignored
+	PaginationPrecedencePage                               // page and pageSize win, limit and offset are ignored
+	PaginationPrecedenceReject                             // mixing styles is reported with ErrMixedPagination, limit and offset are read
+)
+
+// The amount of pages needed to fetch total records, eg: 0 when Limit is 0
+func (p Paginable) TotalPages(total int) int {
+	if p.Limit <= 0 {
+		return 0
+	}
+	return (total + p.Limit - 1) / p.Limit
+}
+
+// Returns whether there are records after the current page
+func (p Paginable) HasNext(total int) bool {
+	return p.Limit > 0 && p.Offset+p.Limit < total
+}
+
+// Same as GetPaginationFromQuery, but following the options. Invalid values are replaced by their
+// fallbacks and reported on the returned ParseErrors, which callers may ignore to stay lenient.
+func GetPaginationFromQueryWithOptions(ctx *fiber.Ctx, opts PaginationOptions) (Paginable, error) {
+	pagination, errs := parsePaginationValues(queryArgsToValues(ctx), opts, true)
+	return pagination, errs.orNil()
+}
+
+func parsePagination(values url.Values, opts Options) (Paginable, ParseErrors) {
+	pagination, errs := parsePaginationValues(values, opts.Pagination, opts.Strict)
+
+	if opts.Cursor == nil {
+		return pagination, errs
+	}
+
+	encoded, ok := lastValue(values, QueryKeyCursor)
+	if !ok || encoded == "" {
+		return pagination, errs
+	}
+
+	cursor, err := opts.Cursor.Decode(encoded)
+	if err != nil {
+		return pagination, append(errs, segment{text: encoded}.error(QueryKeyCursor, err))
+	}
+
+	pagination.Offset = 0
+	pagination.Cursor = &cursor
+	return pagination, errs
+}
+
+// Malformed values are only reported when strict, values above the maximums are always reported
+func parsePaginationValues(values url.Values, opts PaginationOptions, strict bool) (Paginable, ParseErrors) {
+	errs := ParseErrors{}
+
+	pageStyle, mixed := usePageStyle(values, opts.Precedence)
+	if mixed != nil {
+		errs = append(errs, mixed)
+	}
+
+	limitKey := QueryKeyLimit
+	if pageStyle {
+		limitKey = QueryKeyPageSize
+	}
+
+	defaultLimit := opts.DefaultLimit
+	if defaultLimit == 0 {
+		defaultLimit = 10
+	}
+
+	pagination := Paginable{Limit: defaultLimit}
+
+	rawLimit, limit, err := nonNegativeInt(values, limitKey)
+	if err != nil {
+		if strict {
+			errs = append(errs, segment{text: rawLimit}.error(limitKey, err))
+		}
+	} else if limit == 0 && rawLimit != "" {
+		if opts.AllowCountOnly {
+			pagination.Limit = 0
+			pagination.CountOnly = true
+		}
+	} else if limit > 0 {
+		pagination.Limit = limit
+	}
+
+	if opts.MaxLimit > 0 && pagination.Limit > opts.MaxLimit {
+		if opts.RejectAboveMax {
+			errs = append(errs, segment{text: rawLimit}.error(limitKey, fmt.Errorf("%w: %d", ErrLimitTooLarge, opts.MaxLimit)))
+		}
+		pagination.Limit = opts.MaxLimit
+	}
+
+	offsetKey := QueryKeyOffset
+	rawOffset, offset, err := nonNegativeInt(values, offsetKey)
+	if pageStyle {
+		offsetKey = QueryKeyPage
+		rawOffset, offset, err = pageOffset(values, pagination.Limit)
+	}
+
+	if err != nil {
+		// offsets too large to compute are a policy error, as with MaxOffset
+		if strict || errors.Is(err, ErrOffsetTooLarge) {
+			errs = append(errs, segment{text: rawOffset}.error(offsetKey, err))
+		}
+	} else if opts.MaxOffset > 0 && offset > opts.MaxOffset {
+		errs = append(errs, segment{text: rawOffset}.error(offsetKey, fmt.Errorf("%w: %d", ErrOffsetTooLarge, opts.MaxOffset)))
+	} else {
+		pagination.Offset = offset
+	}
+
+	return pagination, errs
+}
+
+// Returns whether the page and pageSize keys should be read instead of limit and offset
+func usePageStyle(values url.Values, precedence PaginationPrecedence) (bool, *SegmentError) {
+	_, hasPage := values[string(QueryKeyPage)]
+	_, hasPageSize := values[string(QueryKeyPageSize)]
+	if !hasPage && !hasPageSize {
+		return false, nil
+	}
+
+	_, hasLimit := values[string(QueryKeyLimit)]
+	_, hasOffset := values[string(QueryKeyOffset)]
+	if !hasLimit && !hasOffset {
+		return true, nil
+	}
+
+	switch precedence {
+	case PaginationPrecedencePage:
+		return true, nil
+	case PaginationPrecedenceReject:
+		key := QueryKeyPage
+		if !hasPage {
+			key = QueryKeyPageSize
+		}
+		raw, _ := lastValue(values, key)
+		return false, segment{text: raw}.error(key, ErrMixedPagination)
+	}
+
+	return false, nil
+}
+
+// Converts the 1-based page into an offset, eg: page 3 with a limit of 10 -> offset 20
+func pageOffset(values url.Values, limit int) (string, int, error) {
+	raw, page, err := nonNegativeInt(values, QueryKeyPage)
+	if err != nil {
+		return raw, 0, err
+	}
+
+	if raw == "" {
+		return raw, 0, nil
+	}
+
+	if page == 0 {
+		return raw, 0, ErrInvalidPage
+	}
+
+	// the offset of pages this far would not fit an int
+	if limit > 0 && page-1 > math.MaxInt/limit {
+		return raw, 0, fmt.Errorf("%w: %d", ErrOffsetTooLarge, math.MaxInt)
+	}
+
+	return raw, (page - 1) * limit, nil
+}
+
+// Returns the raw value of the key along with its integer, which is 0 when the key is missing or empty
+func nonNegativeInt(values url.Values, key QueryKey) (string, int, error) {
+	raw, ok := lastValue(values, key)
+	if !ok || raw == "" {
+		return raw, 0, nil
+	}
+
+	i, err := strconv.Atoi(raw)
+	if err != nil || i < 0 {
+		return raw, 0, fmt.Errorf("%w: %q", ErrInvalidInteger, raw)
+	}
+
+	return raw, i, nil
+}
@@ -0,0 +1,157 @@
+package query
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePaginationValues(t *testing.T) {
+	type args struct {
+		values url.Values
+		opts   PaginationOptions
+		strict bool
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     Paginable
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should return defaults when query is empty",
+			args: args{values: url.Values{}, opts: PaginationOptions{DefaultLimit: 25}},
+			want: Paginable{Limit: 25},
+		},
+		{
+			name: "should clamp limit to max limit",
+			args: args{values: url.Values{"limit": {"1000000"}}, opts: PaginationOptions{MaxLimit: 100}},
+			want: Paginable{Limit: 100},
+		},
+		{
+			name: "should report limit above max limit when rejecting",
+			args: args{values: url.Values{"limit": {"1000000"}}, opts: PaginationOptions{MaxLimit: 100, RejectAboveMax: true}},
+			want: Paginable{Limit: 100},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyLimit, Segment: "1000000", Err: ErrLimitTooLarge},
+			},
+		},
+		{
+			name: "should report offset above max offset",
+			args: args{values: url.Values{"offset": {"5000"}}, opts: PaginationOptions{MaxOffset: 1000}},
+			want: Paginable{Limit: 10},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyOffset, Segment: "5000", Err: ErrOffsetTooLarge},
+			},
+		},
+		{
+			name: "should use default limit when limit is zero",
+			args: args{values: url.Values{"limit": {"0"}}},
+			want: Paginable{Limit: 10},
+		},
+		{
+			name: "should only count when limit is zero and count only is allowed",
+			args: args{values: url.Values{"limit": {"0"}}, opts: PaginationOptions{AllowCountOnly: true}},
+			want: Paginable{Limit: 0, CountOnly: true},
+		},
+		{
+			name: "should drop invalid values when not strict",
+			args: args{values: url.Values{"limit": {"-1"}, "offset": {"abc"}}},
+			want: Paginable{Limit: 10},
+		},
+		{
+			name: "should report invalid values when strict",
+			args: args{values: url.Values{"limit": {"-1"}, "offset": {"abc"}}, strict: true},
+			want: Paginable{Limit: 10},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyLimit, Segment: "-1", Err: ErrInvalidInteger},
+				{Key: QueryKeyOffset, Segment: "abc", Err: ErrInvalidInteger},
+			},
+		},
+		{
+			name: "should convert page and page size to limit and offset",
+			args: args{values: url.Values{"page": {"3"}, "pageSize": {"20"}}},
+			want: Paginable{Limit: 20, Offset: 40},
+		},
+		{
+			name: "should use default limit when there is only a page",
+			args: args{values: url.Values{"page": {"2"}}},
+			want: Paginable{Limit: 10, Offset: 10},
+		},
+		{
+			name: "should apply maximums to page style",
+			args: args{values: url.Values{"page": {"200"}, "pageSize": {"500"}}, opts: PaginationOptions{MaxLimit: 50, MaxOffset: 1000}},
+			want: Paginable{Limit: 50},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyPage, Segment: "200", Err: ErrOffsetTooLarge},
+			},
+		},
+		{
+			name: "should report pages whose offset overflows",
+			args: args{values: url.Values{"page": {"9223372036854775807"}, "pageSize": {"10"}}},
+			want: Paginable{Limit: 10},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyPage, Segment: "9223372036854775807", Err: ErrOffsetTooLarge},
+			},
+		},
+		{
+			name: "should report page zero when strict",
+			args: args{values: url.Values{"page": {"0"}, "pageSize": {"x"}}, strict: true},
+			want: Paginable{Limit: 10},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyPageSize, Segment: "x", Err: ErrInvalidInteger},
+				{Key: QueryKeyPage, Segment: "0", Err: ErrInvalidPage},
+			},
+		},
+		{
+			name: "should prefer limit and offset by default",
+			args: args{values: url.Values{"limit": {"5"}, "offset": {"5"}, "page": {"3"}, "pageSize": {"20"}}},
+			want: Paginable{Limit: 5, Offset: 5},
+		},
+		{
+			name: "should prefer page and page size when configured",
+			args: args{values: url.Values{"limit": {"5"}, "offset": {"5"}, "page": {"3"}, "pageSize": {"20"}}, opts: PaginationOptions{Precedence: PaginationPrecedencePage}},
+			want: Paginable{Limit: 20, Offset: 40},
+		},
+		{
+			name: "should report mixed styles when configured",
+			args: args{values: url.Values{"limit": {"5"}, "pageSize": {"20"}}, opts: PaginationOptions{Precedence: PaginationPrecedenceReject}},
+			want: Paginable{Limit: 5},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyPageSize, Segment: "20", Err: ErrMixedPagination},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := parsePaginationValues(tt.args.values, tt.args.opts, tt.args.strict)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, errs.orNil())
+		})
+	}
+}
+
+func TestPaginablePages(t *testing.T) {
+	tests := []struct {
+		name           string
+		pagination     Paginable
+		total          int
+		wantTotalPages int
+		wantHasNext    bool
+	}{
+		{name: "should count a partial last page", pagination: Paginable{Limit: 10}, total: 21, wantTotalPages: 3, wantHasNext: true},
+		{name: "should have no next on the last page", pagination: Paginable{Limit: 10, Offset: 20}, total: 21, wantTotalPages: 3},
+		{name: "should have no next when the page ends on the total", pagination: Paginable{Limit: 10, Offset: 10}, total: 20, wantTotalPages: 2},
+		{name: "should have no pages when only counting", pagination: Paginable{CountOnly: true}, total: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantTotalPages, tt.pagination.TotalPages(tt.total))
+			assert.Equal(t, tt.wantHasNext, tt.pagination.HasNext(tt.total))
+		})
+	}
+}
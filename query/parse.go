@@ -0,0 +1,236 @@
+package query
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Everything a list endpoint reads from the request query.
+// Can be marshaled to JSON and forwarded as is to backend services.
+type Query struct {
+	Pagination Paginable   `json:"pagination"`
+	Filters    []Filter    `json:"filters"`        // when Expr is set, only set if Expr has no OR nor NOT
+	Expr       *FilterExpr `json:"expr,omitempty"` // filters expression, only set when parsed with Options.Grouping or another Syntax
+	Orders     []Order     `json:"orders"`
+	Search     string      `json:"search"`
+	Fields     []string    `json:"fields,omitempty"` // field paths to respond with, every field when empty
+	Includes   Includes    `json:"includes,omitempty"`
+}
+
+// The filters expression when the query has one, else its filters ANDed
+func (q Query) FilterExpr() FilterExpr {
+	if q.Expr != nil {
+		return *q.Expr
+	}
+
+	expr := And()
+	for _, f := range q.Filters {
+		expr.Children = append(expr.Children, Leaf(f))
+	}
+	return expr
+}
+
+// Grammar of the filters of a query
+type Syntax int
+
+const (
+	SyntaxNative  Syntax = iota // eg: "filters=price[gt]10,name[eq]a", see Options.Grouping
+	SyntaxRSQL                  // eg: "filters=price=gt=10;name==a", see ParseRSQL
+	SyntaxOData                 // eg: "$filter=price gt 10 and name eq 'a'", the whole query is read with ParseOData
+	SyntaxJSONAPI               // eg: "filter[price][gt]=10&sort=-price", the whole query is read with ParseJSONAPI
+)
+
+type Options struct {
+	Pagination   PaginationOptions
+	Strict       bool         // if true, malformed filters and orders are reported on a ParseErrors instead of being dropped
+	Schema       *Schema      // if set, filters, orders, fields and includes outside it are reported and filters get typed values
+	Cursor       *CursorCodec // if set, the cursor key is decoded into Pagination.Cursor
+	Grouping     bool         // if true, filters are parsed with ParseFilterExpr into Query.Expr
+	Syntax       Syntax       // grammar of the filters, other than SyntaxNative always parse into Query.Expr
+	ExprLimits   ExprLimits   // bounds filters expressions when Grouping is set or Syntax is not SyntaxNative
+	RepeatedKeys RepeatedKeys // how filters, order, fields and include keys given more than once are read, merged by default
+}
+
+// Builds the whole Query walking the request query args only once.
+// The returned error is a ParseErrors, along with which the valid parts of the query are returned.
+func Parse(ctx *fiber.Ctx, opts Options) (Query, error) {
+	return parseArgs(queryArgs(ctx), opts)
+}
+
+func parseArgs(args []queryArg, opts Options) (Query, error) {
+	switch opts.Syntax {
+	case SyntaxOData:
+		return parseOData(argsToValues(args), opts)
+	case SyntaxJSONAPI:
+		return parseJSONAPI(args, opts)
+	default:
+		return parse(argsToValues(args), opts)
+	}
+}
+
+func parse(values url.Values, opts Options) (Query, error) {
+	errs := ParseErrors{}
+
+	pagination, paginationErrs := parsePagination(values, opts)
+	errs = append(errs, paginationErrs...)
+
+	filterValues, keyErrs := repeatedValues(values, QueryKeyFilters, opts.RepeatedKeys)
+	errs = append(errs, keyErrs...)
+	filters, expr, filterErrs := parseFilterValues(filterValues, opts)
+	errs = append(errs, filterErrs...)
+
+	orderValues, keyErrs := repeatedValues(values, QueryKeyOrder, opts.RepeatedKeys)
+	errs = append(errs, keyErrs...)
+	orders, orderErrs := parseOrders(orderValues, opts.Schema, opts.Strict)
+	errs = append(errs, orderErrs...)
+
+	q := Query{
+		Pagination: pagination,
+		Filters:    filters,
+		Expr:       expr,
+		Orders:     orders,
+	}
+	errs = append(errs, parseSelection(values, opts, &q)...)
+
+	return q, errs.orNil()
+}
+
+// Reads the search, fields and include keys into q, which every grammar but OData shares
+func parseSelection(values url.Values, opts Options, q *Query) ParseErrors {
+	errs := ParseErrors{}
+
+	search, _ := lastValue(values, QueryKeySearch)
+	q.Search = strings.Trim(search, " ")
+
+	fieldValues, keyErrs := repeatedValues(values, QueryKeyFields, opts.RepeatedKeys)
+	errs = append(errs, keyErrs...)
+	if len(fieldValues) > 0 {
+		var fieldErrs ParseErrors
+		q.Fields, fieldErrs = parseFields(fieldValues, opts.Schema, opts.Strict)
+		errs = append(errs, fieldErrs...)
+	}
+
+	includeValues, keyErrs := repeatedValues(values, QueryKeyInclude, opts.RepeatedKeys)
+	errs = append(errs, keyErrs...)
+	if len(includeValues) > 0 {
+		var includeErrs ParseErrors
+		q.Includes, includeErrs = parseIncludes(includeValues, opts.Schema, opts.Strict)
+		errs = append(errs, includeErrs...)
+	}
+
+	return errs
+}
+
+// Parses the values of the filters key with the grammar of opts. Filters of the flat grammar are concatenated,
+// while expressions are ANDed, as merging them would change their meaning, and bounded together by the limits.
+func parseFilterValues(values []string, opts Options) ([]Filter, *FilterExpr, ParseErrors) {
+	if len(values) == 0 {
+		return []Filter{}, nil, ParseErrors{}
+	}
+
+	if opts.Syntax != SyntaxRSQL && !opts.Grouping {
+		filters, errs := parseFilters(values, opts.Schema, opts.Strict)
+		return filters, nil, errs
+	}
+
+	parseExpr := parseFilterExpr
+	if opts.Syntax == SyntaxRSQL {
+		parseExpr = parseRSQL
+	}
+
+	errs := ParseErrors{}
+	exprs := []FilterExpr{}
+	for _, value := range values {
+		expr, exprErrs := parseExpr(value, opts.ExprLimits, opts.Schema)
+		errs = append(errs, exprErrs...)
+		if expr != nil {
+			exprs = append(exprs, *expr)
+		}
+	}
+
+	// any malformed value drops the whole expression, as dropping one of its operands would change its meaning
+	if len(errs) > 0 || len(exprs) == 0 {
+		return []Filter{}, nil, errs
+	}
+
+	expr := exprs[0]
+	if len(exprs) > 1 {
+		expr = And()
+		for _, e := range exprs {
+			if e.Kind == FilterExprAnd {
+				expr.Children = append(expr.Children, e.Children...)
+			} else {
+				expr.Children = append(expr.Children, e)
+			}
+		}
+
+		if expr.size() > opts.ExprLimits.withDefaults().MaxNodes {
+			last := values[len(values)-1]
+			return []Filter{}, nil, append(errs, segment{text: last}.error(QueryKeyFilters, ErrExprTooLarge))
+		}
+	}
+
+	filters := []Filter{}
+	if expr.IsConjunction() {
+		filters = expr.Filters()
+	}
+	return filters, &expr, errs
+}
+
+// Repeated keys keep their last value, but for the filters, order, fields and include keys, see RepeatedKeys
+func lastValue(values url.Values, key QueryKey) (string, bool) {
+	v := values[string(key)]
+	if len(v) == 0 {
+		return "", false
+	}
+	return v[len(v)-1], true
+}
+
+func queryArgsToValues(c *fiber.Ctx) url.Values {
+	return argsToValues(queryArgs(c))
+}
+
+// A query key and its value, in the order they appear on the request
+type queryArg struct {
+	key   string
+	value string
+}
+
+// Every query arg of the request, including repeated keys
+func queryArgs(c *fiber.Ctx) []queryArg {
+	args := []queryArg{}
+
+	c.Context().QueryArgs().VisitAll(func(key []byte, value []byte) {
+		args = append(args, queryArg{key: string(key), value: string(value)})
+	})
+
+	return args
+}
+
+func argsToValues(args []queryArg) url.Values {
+	values := url.Values{}
+	for _, arg := range args {
+		values.Add(arg.key, arg.value)
+	}
+	return values
+}
+
+// url.Values does not keep the order between keys, so they are sorted to get the same args every time
+func valuesToArgs(values url.Values) []queryArg {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []queryArg{}
+	for _, key := range keys {
+		for _, value := range values[key] {
+			args = append(args, queryArg{key: key, value: value})
+		}
+	}
+	return args
+}
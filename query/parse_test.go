@@ -0,0 +1,187 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestParse(t *testing.T) {
+	type args struct {
+		values url.Values
+		opts   Options
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Query
+		wantErr error
+	}{
+		{
+			name: "should return defaults when query is empty",
+			args: args{values: url.Values{}},
+			want: Query{
+				Pagination: Paginable{Limit: 10, Offset: 0},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+		},
+		{
+			name: "should parse every key",
+			args: args{values: url.Values{
+				"limit":   {"20"},
+				"offset":  {"40"},
+				"filters": {"a[eq]b"},
+				"order":   {"c:asc"},
+				"search":  {"  abc  "},
+			}},
+			want: Query{
+				Pagination: Paginable{Limit: 20, Offset: 40},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "b"}},
+				Orders:     []Order{{Field: "c", Asc: true}},
+				Search:     "abc",
+			},
+		},
+		{
+			name: "should use default limit and clamp to max limit",
+			args: args{
+				values: url.Values{"limit": {"1000"}},
+				opts:   Options{Pagination: PaginationOptions{DefaultLimit: 25, MaxLimit: 100}},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 100},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+		},
+		{
+			name: "should use default limit when limit is invalid",
+			args: args{
+				values: url.Values{"limit": {"-1"}},
+				opts:   Options{Pagination: PaginationOptions{DefaultLimit: 25, MaxLimit: 100}},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 25},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+		},
+		{
+			name: "should drop malformed values when not strict",
+			args: args{values: url.Values{
+				"filters": {"a[eq]b,c[xx]d"},
+				"order":   {"e:asc,f"},
+			}},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "b"}},
+				Orders:     []Order{{Field: "e", Asc: true}},
+			},
+		},
+		{
+			name: "should report malformed values when strict",
+			args: args{
+				values: url.Values{"filters": {"a[eq]b,c[xx]d"}},
+				opts:   Options{Strict: true},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "b"}},
+				Orders:     []Order{},
+			},
+			wantErr: ErrUnknownOperator,
+		},
+		{
+			name: "should type filters with the schema",
+			args: args{
+				values: url.Values{"filters": {"price[gt]10"}, "order": {"price:desc"}},
+				opts:   Options{Schema: testSchema},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)}},
+				Orders:     []Order{{Field: "price", Asc: false}},
+			},
+		},
+		{
+			name: "should report values outside the schema",
+			args: args{
+				values: url.Values{"filters": {"price[gt]10"}, "order": {"name:desc"}},
+				opts:   Options{Schema: testSchema},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: int64(10)}},
+				Orders:     []Order{},
+			},
+			wantErr: ErrFieldNotSortable,
+		},
+		{
+			name: "should report invalid cursor",
+			args: args{
+				values: url.Values{"cursor": {"abc"}},
+				opts:   Options{Cursor: &CursorCodec{Secret: []byte("secret")}},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+			wantErr: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.args.values, tt.args.opts)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	expect := assert.New(t)
+	codec := CursorCodec{Secret: []byte("secret")}
+	cursor := Cursor{Orders: []Order{{Field: "id", Asc: true}}, Values: []interface{}{int64(1)}}
+
+	encoded, err := codec.Encode(cursor)
+	expect.Nil(err)
+
+	got, err := parse(url.Values{"cursor": {encoded}, "offset": {"20"}, "order": {"id:asc"}}, Options{Cursor: &codec})
+	expect.Nil(err)
+	expect.Equal(0, got.Pagination.Offset)
+	expect.True(reflect.DeepEqual(&cursor, got.Pagination.Cursor), "got: %v, want: %v", got.Pagination.Cursor, cursor)
+}
+
+func TestParseFromFiberCtx(t *testing.T) {
+	expect := assert.New(t)
+
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI("/products?limit=5&filters=a%5Beq%5Db&order=c:desc&search=x")
+	ctx := app.AcquireCtx(fctx)
+	defer app.ReleaseCtx(ctx)
+
+	got, err := Parse(ctx, Options{})
+	expect.Nil(err)
+
+	body, err := json.Marshal(got)
+	expect.Nil(err)
+	expect.JSONEq(`{
+		"pagination": {"limit": 5, "offset": 0},
+		"filters": [{"field": "a", "operation": "eq", "value": "b"}],
+		"orders": [{"field": "c", "asc": false}],
+		"search": "x"
+	}`, string(body))
+}
@@ -1,7 +1,8 @@
 package query
 
 import (
-	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
 	"strings"
 	"unicode"
@@ -10,14 +11,17 @@ import (
 )
 
 type Paginable struct {
-	Limit  int `json:"limit"`  // Maximun amount of records that should be fetched
-	Offset int `json:"offset"` // Index to fetch records after
+	Limit     int     `json:"limit"`               // Maximun amount of records that should be fetched
+	Offset    int     `json:"offset"`              // Index to fetch records after, also set from the page query key
+	Cursor    *Cursor `json:"cursor,omitempty"`    // Keyset position to fetch records after, replaces Offset when set
+	CountOnly bool    `json:"countOnly,omitempty"` // if true, only the amount of records is wanted, see PaginationOptions.AllowCountOnly
 }
 
 type Filter struct {
 	Field     string         `json:"field"`     // the field to filter by eg: "price"
 	Value     string         `json:"value"`     // string representation of the value to apply the filter, eg: "10", "tag1;tag2"
 	Operation FilterOperator `json:"operation"` // the operation to use for filtering, eg: gt (greather than)
+	Typed     interface{}    `json:"-"`         // value converted to the schema field type, only set when parsed with a Schema
 }
 
 type FilterOperator string
@@ -33,6 +37,15 @@ const (
 	FilterOperatorStartsWith         FilterOperator = "startsWith"
 	FilterOperatorEndsWith           FilterOperator = "endsWith"
 	FilterOperatorContains           FilterOperator = "contains"
+	FilterOperatorBetween            FilterOperator = "between"   // inclusive range, eg: "price[between]10;20"
+	FilterOperatorNotIn              FilterOperator = "nin"       // eg: "status[nin]closed;archived"
+	FilterOperatorIsNull             FilterOperator = "isNull"    // takes no value, eg: "deletedAt[isNull]"
+	FilterOperatorNotNull            FilterOperator = "notNull"   // takes no value, eg: "deletedAt[notNull]"
+	FilterOperatorEqualFold          FilterOperator = "ieq"       // case insensitive eq
+	FilterOperatorContainsFold       FilterOperator = "icontains" // case insensitive contains
+	// Matches a regular expression. It is not valid on its own,
+	// a Schema field must list it on its operators for it to be parsed.
+	FilterOperatorRegex FilterOperator = "regex"
 )
 
 func (f *FilterOperator) IsValid() bool {
@@ -42,7 +55,49 @@ func (f *FilterOperator) IsValid() bool {
 		FilterOperatorNotEqual, FilterOperatorEqual,
 		FilterOperatorStartsWith, FilterOperatorEndsWith,
 		FilterOperatorContains, FilterOperatorGreaterThan,
-		FilterOperatorGretherThanOrEqual, FilterOperatorLessThanOrEqual:
+		FilterOperatorGretherThanOrEqual, FilterOperatorLessThanOrEqual,
+		FilterOperatorBetween, FilterOperatorNotIn,
+		FilterOperatorIsNull, FilterOperatorNotNull,
+		FilterOperatorEqualFold, FilterOperatorContainsFold:
+		return true
+	}
+
+	return false
+}
+
+// How many values an operator expects
+type Arity int
+
+const (
+	ArityNone Arity = iota // eg: isNull
+	ArityOne               // eg: eq
+	ArityTwo               // two values separated by QueryParamSeparatorArray, eg: between
+	ArityMany              // one or more values separated by QueryParamSeparatorArray, eg: in
+)
+
+func (f FilterOperator) Arity() Arity {
+	switch f {
+	case FilterOperatorIsNull, FilterOperatorNotNull:
+		return ArityNone
+	case FilterOperatorBetween:
+		return ArityTwo
+	case FilterOperatorIn, FilterOperatorNotIn:
+		return ArityMany
+	}
+
+	return ArityOne
+}
+
+// Returns whether the operator takes values separated by QueryParamSeparatorArray
+func (f FilterOperator) isList() bool {
+	return f.Arity() == ArityTwo || f.Arity() == ArityMany
+}
+
+// Returns whether the operator matches Filter.Value as text, rather than Filter.Typed, eg: contains
+func (f FilterOperator) isText() bool {
+	switch f {
+	case FilterOperatorStartsWith, FilterOperatorEndsWith, FilterOperatorContains,
+		FilterOperatorEqualFold, FilterOperatorContainsFold, FilterOperatorRegex:
 		return true
 	}
 
@@ -57,11 +112,16 @@ type Order struct {
 type QueryKey string
 
 const (
-	QueryKeyLimit   QueryKey = "limit"
-	QueryKeyOffset  QueryKey = "offset"
-	QueryKeySearch  QueryKey = "search"
-	QueryKeyOrder   QueryKey = "order"
-	QueryKeyFilters QueryKey = "filters"
+	QueryKeyLimit    QueryKey = "limit"
+	QueryKeyOffset   QueryKey = "offset"
+	QueryKeySearch   QueryKey = "search"
+	QueryKeyOrder    QueryKey = "order"
+	QueryKeyFilters  QueryKey = "filters"
+	QueryKeyCursor   QueryKey = "cursor"
+	QueryKeyPage     QueryKey = "page"     // 1-based, alternative to offset
+	QueryKeyPageSize QueryKey = "pageSize" // alternative to limit
+	QueryKeyFields   QueryKey = "fields"
+	QueryKeyInclude  QueryKey = "include"
 )
 
 func hasALetter(s string) bool {
@@ -91,38 +151,53 @@ func getPositiveIntFromQueryWithFallback(ctx *fiber.Ctx, key string, fallbackVal
 	return i
 }
 
-// eg: "i[love]brazil" -> Filter{Field: "i", Operation: FilterOperator("love"), Value: "brazil"}
-func getFilter(value string) (Filter, error) {
-	opStart := strings.Index(value, string(QueryParamSeparatorOperatorStart))
+// eg: "i[love]brazil" -> Filter{Field: "i", Operation: FilterOperator("love"), Value: "brazil"}.
+// The regex operator is only accepted when allowRegex is true.
+func getFilter(value string, allowRegex bool) (Filter, error) {
+	opStart := indexUnescaped(value, QueryParamSeparatorOperatorStart)
 	if opStart == -1 {
-		return Filter{}, errors.New("invalid filter")
+		return Filter{}, ErrMissingBracket
 	}
 
-	opEnd := strings.Index(value, string(QueryParamSeparatorOperatorEnd))
-	if opEnd == -1 {
-		return Filter{}, errors.New("invalid filter")
+	opLen := indexUnescaped(value[opStart:], QueryParamSeparatorOperatorEnd)
+	if opLen == -1 {
+		return Filter{}, ErrMissingBracket
 	}
+	opEnd := opStart + opLen
 
 	o := FilterOperator(value[opStart+1 : opEnd])
-	if !o.IsValid() {
-		return Filter{}, errors.New("invalid filter operator")
+	if !o.IsValid() && !(allowRegex && o == FilterOperatorRegex) {
+		return Filter{}, fmt.Errorf("%w: %q", ErrUnknownOperator, o)
 	}
 
-	f := value[:opStart]
+	f := unescape(value[:opStart], false)
 	if !hasALetter(f) {
-		return Filter{}, errors.New("invalid filter field")
+		return Filter{}, ErrInvalidField
+	}
+
+	raw := value[opEnd+1:]
+	if o.Arity() == ArityNone {
+		if raw != "" {
+			return Filter{}, fmt.Errorf("%w: %q", ErrUnexpectedValue, o)
+		}
+		return Filter{Field: f, Operation: o}, nil
 	}
 
-	v := value[opEnd+1:]
-	if v == "" {
-		return Filter{}, errors.New("invalid filter value")
+	if raw == "" {
+		return Filter{}, ErrEmptyValue
 	}
 
-	return Filter{
+	filter := Filter{
 		Field:     f,
 		Operation: o,
-		Value:     v,
-	}, nil
+		Value:     unescape(raw, o.isList()),
+	}
+
+	if o.Arity() == ArityTwo && len(filter.Values()) != 2 {
+		return Filter{}, fmt.Errorf("%w: %q takes 2", ErrValueCount, o)
+	}
+
+	return filter, nil
 }
 
 type QueryParamSeparator string
@@ -135,10 +210,6 @@ const (
 	QueryParamSeparatorOperatorEnd   QueryParamSeparator = "]"
 )
 
-func splitStringBySeparator(s string, sep QueryParamSeparator) []string {
-	return strings.Split(s, string(sep))
-}
-
 func GetPaginationFromQuery(ctx *fiber.Ctx) Paginable {
 	return Paginable{
 		Limit:  getPositiveIntFromQueryWithFallback(ctx, string(QueryKeyLimit), 10),
@@ -146,70 +217,118 @@ func GetPaginationFromQuery(ctx *fiber.Ctx) Paginable {
 	}
 }
 
-func getFilterFromQuery(queryParams map[string]string) []Filter {
-	if value, ok := queryParams[string(QueryKeyFilters)]; ok {
-		return getFilterFields(value)
+func getFilterFromQuery(queryParams url.Values) []Filter {
+	if values, ok := queryParams[string(QueryKeyFilters)]; ok {
+		return getFilterFields(values)
 	}
 
 	return []Filter{}
 }
 
-func getFilterFields(value string) []Filter {
-	filters := []Filter{}
+func getFilterFields(values []string) []Filter {
+	filters, _ := parseFilters(values, nil, false)
+	return filters
+}
 
-	fields := splitStringBySeparator(value, QueryParamSeparatorMap)
+// Parses every filter of the filters query values. Malformed filters are dropped, or reported when strict.
+// When a schema is given, filters it does not allow are always reported and the others get typed values.
+func parseFilters(values []string, schema *Schema, strict bool) ([]Filter, ParseErrors) {
+	filters := []Filter{}
+	errs := ParseErrors{}
 
-	for _, field := range fields {
-		f, err := getFilter(field)
+	for _, segment := range splitRepeatedSegments(values, QueryParamSeparatorMap) {
+		f, err := getFilter(segment.text, schema != nil)
 		if err != nil {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyFilters, err))
+			}
 			continue
 		}
 
+		if schema != nil {
+			f, err = schema.CoerceFilter(f)
+			if err != nil {
+				errs = append(errs, segment.error(QueryKeyFilters, err))
+				continue
+			}
+		}
+
 		filters = append(filters, f)
 	}
 
-	return filters
+	return filters, errs
 }
 
 func GetFilterFromQuery(c *fiber.Ctx) []Filter {
-	queryParams := queryParamsToMap(c)
+	queryParams := queryArgsToValues(c)
 	filter := getFilterFromQuery(queryParams)
 	return filter
 }
 
-func getOrderFromQuery(queryParams map[string]string) []Order {
-	if value, ok := queryParams[string(QueryKeyOrder)]; ok {
-		return getOrderFields(value)
+func getOrderFromQuery(queryParams url.Values) []Order {
+	if values, ok := queryParams[string(QueryKeyOrder)]; ok {
+		return getOrderFields(values)
 	}
 
 	return []Order{}
 }
 
-func getOrderFields(value string) []Order {
-	order := []Order{}
+func getOrderFields(values []string) []Order {
+	orders, _ := parseOrders(values, nil, false)
+	return orders
+}
 
-	sorts := splitStringBySeparator(value, QueryParamSeparatorMap)
+// Parses every order of the order query values. Malformed orders are dropped, or reported when strict,
+// in which case directions other than asc and desc are errors instead of meaning descending.
+// When a schema is given, orders it does not allow are always reported.
+func parseOrders(values []string, schema *Schema, strict bool) ([]Order, ParseErrors) {
+	orders := []Order{}
+	errs := ParseErrors{}
 
-	for _, sort := range sorts {
-		s := splitStringBySeparator(sort, QueryParamSeparatorValue)
-		if len(s) != 2 {
+	for _, segment := range splitRepeatedSegments(values, QueryParamSeparatorMap) {
+		o, err := parseOrder(segment.text, strict)
+		if err != nil {
+			if strict {
+				errs = append(errs, segment.error(QueryKeyOrder, err))
+			}
 			continue
 		}
 
-		o, err := getOrder(s[0], s[1])
-		if err != nil {
-			continue
+		if schema != nil {
+			if err := schema.ValidateOrder(o); err != nil {
+				errs = append(errs, segment.error(QueryKeyOrder, err))
+				continue
+			}
 		}
 
-		order = append(order, o)
+		orders = append(orders, o)
 	}
 
-	return order
+	return orders, errs
+}
+
+// eg: "price:asc" -> Order{Field: "price", Asc: true}
+func parseOrder(value string, strict bool) (Order, error) {
+	s := splitUnescaped(value, QueryParamSeparatorValue, true)
+	if len(s) != 2 {
+		return Order{}, ErrMissingDirection
+	}
+
+	field := unescape(s[0], false)
+
+	if strict {
+		direction := strings.ToLower(s[1])
+		if direction != "asc" && direction != "desc" {
+			return Order{}, fmt.Errorf("%w: %q", ErrInvalidDirection, s[1])
+		}
+	}
+
+	return getOrder(field, s[1])
 }
 
 func getOrder(field, value string) (Order, error) {
 	if !hasALetter(field) {
-		return Order{}, errors.New("invalid order field")
+		return Order{}, ErrInvalidField
 	}
 
 	return Order{
@@ -219,32 +338,22 @@ func getOrder(field, value string) (Order, error) {
 }
 
 func GetOrderFromQuery(c *fiber.Ctx) []Order {
-	queryParams := queryParamsToMap(c)
+	queryParams := queryArgsToValues(c)
 	order := getOrderFromQuery(queryParams)
 	return order
 }
 
 func GetSearchFromQuery(c *fiber.Ctx) string {
-	queryParams := queryParamsToMap(c)
+	queryParams := queryArgsToValues(c)
 	search := getSearchFromQuery(queryParams)
 	return search
 }
 
-func getSearchFromQuery(queryParams map[string]string) string {
+func getSearchFromQuery(queryParams url.Values) string {
 	trimmable := " "
-	if value, ok := queryParams[string(QueryKeySearch)]; ok {
+	if value, ok := lastValue(queryParams, QueryKeySearch); ok {
 		return strings.Trim(value, trimmable)
 	}
 
 	return ""
 }
-
-func queryParamsToMap(c *fiber.Ctx) map[string]string {
-	queryParams := make(map[string]string)
-
-	c.Context().QueryArgs().VisitAll(func(key []byte, value []byte) {
-		queryParams[string(key)] = string(value)
-	})
-
-	return queryParams
-}
@@ -1,6 +1,7 @@
 package query
 
 import (
+	"net/url"
 	"reflect"
 	"testing"
 
@@ -9,7 +10,7 @@ import (
 
 func TestGetOrderFromQuery(t *testing.T) {
 	type args struct {
-		queryParams map[string]string
+		queryParams url.Values
 	}
 	tests := []struct {
 		name string
@@ -18,50 +19,50 @@ func TestGetOrderFromQuery(t *testing.T) {
 	}{
 		{
 			name: "should return empty slice when query params is empty",
-			args: args{queryParams: map[string]string{}},
+			args: args{queryParams: url.Values{}},
 			want: []Order{},
 		},
 		{
 			name: "should return empty slice when query params has no order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
 			}},
 			want: []Order{},
 		},
 		{
 			name: "should return empty slice when query params has empty order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {""},
 			}},
 			want: []Order{},
 		},
 		{
 			name: "should return empty slice when query params has invalid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "invalid",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"invalid"},
 			}},
 			want: []Order{},
 		},
 		{
 			name: "should return empty slice when query params has invalid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "invalid,invalid",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"invalid,invalid"},
 			}},
 			want: []Order{},
 		},
 		{
 			name: "should return Order slice when query params has valid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "id:asc",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"id:asc"},
 			}},
 			want: []Order{
 				{
@@ -72,10 +73,10 @@ func TestGetOrderFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Order slice when query params has valid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "id:asc,name:desc",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"id:asc,name:desc"},
 			}},
 			want: []Order{
 				{
@@ -90,10 +91,10 @@ func TestGetOrderFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Order slice when query params has valid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "id:asc,name:desc,age:asc",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"id:asc,name:desc,age:asc"},
 			}},
 			want: []Order{
 				{
@@ -112,10 +113,10 @@ func TestGetOrderFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Order slice when query params has valid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "a:.,b:*(),c:???",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"a:.,b:*(),c:???"},
 			}},
 			want: []Order{
 				{
@@ -134,10 +135,10 @@ func TestGetOrderFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Order slice with omitted values when query params has invalid order",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"order":  "a:asc,.:desc,c:asc",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"order":  {"a:asc,.:desc,c:asc"},
 			}},
 			want: []Order{
 				{
@@ -280,7 +281,7 @@ func TestFilterOperatorIsValid(t *testing.T) {
 
 func TestGetFilterFromQuery(t *testing.T) {
 	type args struct {
-		queryParams map[string]string
+		queryParams url.Values
 	}
 	tests := []struct {
 		name string
@@ -289,63 +290,63 @@ func TestGetFilterFromQuery(t *testing.T) {
 	}{
 		{
 			name: "should return empty slice when query params has no filters",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return empty slice when query params has invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return empty slice when query params has invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a:asc",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a:asc"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return empty slice when query params has invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a:asc,b:desc",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a:asc,b:desc"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return empty slice when query params has invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eqb",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eqb"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return empty slice when query params has invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "aeq]b",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"aeq]b"},
 			}},
 			want: []Filter{},
 		},
 		{
 			name: "should return Filter slice when query params has valid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b"},
 			}},
 			want: []Filter{
 				{
@@ -357,10 +358,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice when query params has valid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[contains]b",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[contains]b"},
 			}},
 			want: []Filter{
 				{
@@ -372,10 +373,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice with omitted values when query params has valid and invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b,c[]d,e[eq]f",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b,c[]d,e[eq]f"},
 			}},
 			want: []Filter{
 				{
@@ -392,10 +393,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice with omitted values when query params has valid and invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b,[eq]d,e[contains]f",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b,[eq]d,e[contains]f"},
 			}},
 			want: []Filter{
 				{
@@ -412,10 +413,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice with omitted values when query params has valid and invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b,t[eq],e[contains]f",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b,t[eq],e[contains]f"},
 			}},
 			want: []Filter{
 				{
@@ -432,10 +433,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice with omitted values when query params has valid and invalid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b,[eq],e[contains]f",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b,[eq],e[contains]f"},
 			}},
 			want: []Filter{
 				{
@@ -452,10 +453,10 @@ func TestGetFilterFromQuery(t *testing.T) {
 		},
 		{
 			name: "should return Filter slice when query params has valid filters",
-			args: args{queryParams: map[string]string{
-				"limit":   "10",
-				"offset":  "0",
-				"filters": "a[eq]b;c[eq]d;e[eq]f",
+			args: args{queryParams: url.Values{
+				"limit":   {"10"},
+				"offset":  {"0"},
+				"filters": {"a[eq]b;c[eq]d;e[eq]f"},
 			}},
 			want: []Filter{
 				{
@@ -477,7 +478,7 @@ func TestGetFilterFromQuery(t *testing.T) {
 
 func TestGetSearchFromQuery(t *testing.T) {
 	type args struct {
-		queryParams map[string]string
+		queryParams url.Values
 	}
 
 	tests := []struct {
@@ -487,63 +488,63 @@ func TestGetSearchFromQuery(t *testing.T) {
 	}{
 		{
 			name: "should return empty string when query params has no search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
 			}},
 			want: "",
 		},
 		{
 			name: "should return empty string when query params has empty search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": "",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {""},
 			}},
 			want: "",
 		},
 		{
 			name: "should return empty string when query params has empty search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": " ",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {" "},
 			}},
 			want: "",
 		},
 		{
 			name: "should return empty string when query params has empty search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": "       ",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {"       "},
 			}},
 			want: "",
 		},
 		{
 			name: "should return search string when query params has valid search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": "abc",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {"abc"},
 			}},
 			want: "abc",
 		},
 		{
 			name: "should return search string when query params has valid search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": "abc def",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {"abc def"},
 			}},
 			want: "abc def",
 		},
 		{
 			name: "should return search string when query params has valid search",
-			args: args{queryParams: map[string]string{
-				"limit":  "10",
-				"offset": "0",
-				"search": "          abc def                    ",
+			args: args{queryParams: url.Values{
+				"limit":  {"10"},
+				"offset": {"0"},
+				"search": {"          abc def                    "},
 			}},
 			want: "abc def",
 		},
@@ -0,0 +1,48 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+)
+
+var ErrRepeatedKey = errors.New("query key can only be given once")
+
+// How the filters, order, fields and include keys are read when given more than once,
+// eg: "filters=price[gt]10&filters=name[eq]a", as some clients encode lists that way
+type RepeatedKeys int
+
+const (
+	RepeatedKeysMerge  RepeatedKeys = iota // every value is read, as if they were given on a single key
+	RepeatedKeysFirst                      // only the first value is read
+	RepeatedKeysLast                       // only the last value is read, as with any other key
+	RepeatedKeysReject                     // the key is reported with ErrRepeatedKey and none of its values is read
+)
+
+// The values of key to read according to the policy. Rejections are always reported, as they are not syntax errors.
+func repeatedValues(values url.Values, key QueryKey, policy RepeatedKeys) ([]string, ParseErrors) {
+	v := values[string(key)]
+	if len(v) <= 1 {
+		return v, ParseErrors{}
+	}
+
+	switch policy {
+	case RepeatedKeysFirst:
+		return v[:1], ParseErrors{}
+	case RepeatedKeysLast:
+		return v[len(v)-1:], ParseErrors{}
+	case RepeatedKeysReject:
+		return nil, ParseErrors{segment{text: v[1]}.error(key, ErrRepeatedKey)}
+	default:
+		return v, ParseErrors{}
+	}
+}
+
+// Splits each value of a repeated key on its own, so an escape or a quote left open by one value does not
+// swallow the next, with positions relative to their value, eg: ["a,b", "c"] -> "a" at 0, "b" at 2 and "c" at 0
+func splitRepeatedSegments(values []string, sep QueryParamSeparator) []segment {
+	segments := []segment{}
+	for _, value := range values {
+		segments = append(segments, splitSegments(value, sep)...)
+	}
+	return segments
+}
@@ -0,0 +1,255 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestParseRepeatedKeys(t *testing.T) {
+	values := url.Values{
+		"filters": {"a[eq]1", "b[eq]2"},
+		"order":   {"a:asc", "b:desc"},
+		"fields":  {"a,b", "b,c"},
+	}
+
+	type args struct {
+		values url.Values
+		opts   Options
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    Query
+		wantErr error
+	}{
+		{
+			name: "should merge repeated keys by default",
+			args: args{values: values},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "a", Operation: FilterOperatorEqual, Value: "1"},
+					{Field: "b", Operation: FilterOperatorEqual, Value: "2"},
+				},
+				Orders: []Order{{Field: "a", Asc: true}, {Field: "b", Asc: false}},
+				Fields: []string{"a", "b", "c"},
+			},
+		},
+		{
+			name: "should keep the first value",
+			args: args{values: values, opts: Options{RepeatedKeys: RepeatedKeysFirst}},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "1"}},
+				Orders:     []Order{{Field: "a", Asc: true}},
+				Fields:     []string{"a", "b"},
+			},
+		},
+		{
+			name: "should keep the last value",
+			args: args{values: values, opts: Options{RepeatedKeys: RepeatedKeysLast}},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "b", Operation: FilterOperatorEqual, Value: "2"}},
+				Orders:     []Order{{Field: "b", Asc: false}},
+				Fields:     []string{"b", "c"},
+			},
+		},
+		{
+			name: "should reject repeated keys even when not strict",
+			args: args{values: values, opts: Options{RepeatedKeys: RepeatedKeysReject}},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+			wantErr: ErrRepeatedKey,
+		},
+		{
+			name: "should not reject keys given once",
+			args: args{
+				values: url.Values{"filters": {"a[eq]1"}, "limit": {"5"}},
+				opts:   Options{RepeatedKeys: RepeatedKeysReject},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 5},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "1"}},
+				Orders:     []Order{},
+			},
+		},
+		{
+			name: "should and repeated expressions instead of merging them",
+			args: args{
+				values: url.Values{"filters": {"a[eq]1|b[eq]2", "c[eq]3"}},
+				opts:   Options{Grouping: true},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Expr: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{
+					Or(
+						Leaf(Filter{Field: "a", Operation: FilterOperatorEqual, Value: "1"}),
+						Leaf(Filter{Field: "b", Operation: FilterOperatorEqual, Value: "2"}),
+					),
+					Leaf(Filter{Field: "c", Operation: FilterOperatorEqual, Value: "3"}),
+				}},
+				Orders: []Order{},
+			},
+		},
+		{
+			name: "should and repeated rsql expressions",
+			args: args{
+				values: url.Values{"filters": {"a==1;b==2", "c==3"}},
+				opts:   Options{Syntax: SyntaxRSQL},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "a", Operation: FilterOperatorEqual, Value: "1"},
+					{Field: "b", Operation: FilterOperatorEqual, Value: "2"},
+					{Field: "c", Operation: FilterOperatorEqual, Value: "3"},
+				},
+				Expr: &FilterExpr{Kind: FilterExprAnd, Children: []FilterExpr{
+					Leaf(Filter{Field: "a", Operation: FilterOperatorEqual, Value: "1"}),
+					Leaf(Filter{Field: "b", Operation: FilterOperatorEqual, Value: "2"}),
+					Leaf(Filter{Field: "c", Operation: FilterOperatorEqual, Value: "3"}),
+				}},
+				Orders: []Order{},
+			},
+		},
+		{
+			name: "should bound repeated expressions together",
+			args: args{
+				values: url.Values{"filters": {"a[eq]1,b[eq]2", "c[eq]3"}},
+				opts:   Options{Grouping: true, ExprLimits: ExprLimits{MaxNodes: 3}},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+			wantErr: ErrExprTooLarge,
+		},
+		{
+			name: "should drop the whole expression when a repeated value is malformed",
+			args: args{
+				values: url.Values{"filters": {"a[eq]1", "b[eqq]2"}},
+				opts:   Options{Grouping: true},
+			},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{},
+				Orders:     []Order{},
+			},
+			wantErr: ErrUnknownOperator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.args.values, tt.args.opts)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestParseRepeatedKeysSeparately(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   url.Values
+		strict   bool
+		want     Query
+		wantErrs []*SegmentError
+	}{
+		{
+			name:   "should not let a dangling escape swallow the next value",
+			values: url.Values{"filters": {`name[eq]x\`, "role[eq]admin"}, "fields": {`"a`, "b"}},
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters: []Filter{
+					{Field: "name", Operation: FilterOperatorEqual, Value: `x\`},
+					{Field: "role", Operation: FilterOperatorEqual, Value: "admin"},
+				},
+				Orders: []Order{},
+				Fields: []string{`"a`, "b"},
+			},
+		},
+		{
+			name:   "should report positions relative to their value",
+			values: url.Values{"filters": {"a[eq]1", "b[xx]2"}, "order": {"a:asc", "b"}},
+			strict: true,
+			want: Query{
+				Pagination: Paginable{Limit: 10},
+				Filters:    []Filter{{Field: "a", Operation: FilterOperatorEqual, Value: "1"}},
+				Orders:     []Order{{Field: "a", Asc: true}},
+			},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "b[xx]2", Position: 0, Err: ErrUnknownOperator},
+				{Key: QueryKeyOrder, Segment: "b", Position: 0, Err: ErrMissingDirection},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parse(tt.values, Options{Strict: tt.strict})
+			assertSegmentErrors(t, tt.wantErrs, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestGetFromQueryRepeatedKeys(t *testing.T) {
+	expect := assert.New(t)
+
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI("/products?filters=a[eq]1&filters=&filters=b[eq]2&order=a:asc&order=b:desc&search=x&search=y")
+	ctx := app.AcquireCtx(fctx)
+	defer app.ReleaseCtx(ctx)
+
+	expect.Equal([]Filter{
+		{Field: "a", Operation: FilterOperatorEqual, Value: "1"},
+		{Field: "b", Operation: FilterOperatorEqual, Value: "2"},
+	}, GetFilterFromQuery(ctx))
+	expect.Equal([]Order{{Field: "a", Asc: true}, {Field: "b", Asc: false}}, GetOrderFromQuery(ctx))
+	expect.Equal("y", GetSearchFromQuery(ctx))
+
+	filters, err := GetFilterFromQueryStrict(ctx)
+	expect.Nil(err)
+	expect.Len(filters, 2)
+
+	expr, err := GetFilterExprFromQuery(ctx, ExprLimits{})
+	expect.Nil(err)
+	expect.Equal(FilterExprAnd, expr.Kind)
+	expect.Len(expr.Children, 2)
+
+	fctx.Request.SetRequestURI(`/products?filters=name[eq]x%5C&filters=role[eq]admin`)
+	expect.Equal([]Filter{
+		{Field: "name", Operation: FilterOperatorEqual, Value: `x\`},
+		{Field: "role", Operation: FilterOperatorEqual, Value: "admin"},
+	}, GetFilterFromQuery(ctx))
+}
+
+func TestParseJSONAPIRepeatedSort(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := parseJSONAPI(splitQueryString("sort=a&sort=-b&filter[a]=1&filter[a]=2"), Options{})
+	expect.Nil(err)
+	expect.Equal([]Order{{Field: "a", Asc: true}, {Field: "b", Asc: false}}, got.Orders)
+	expect.Len(got.Filters, 2)
+
+	_, err = parseJSONAPI(splitQueryString("sort=a&sort=-b"), Options{RepeatedKeys: RepeatedKeysReject})
+	expect.True(errors.Is(err, ErrRepeatedKey))
+}
@@ -0,0 +1,511 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrNotExpressible = errors.New("query can not be expressed in the syntax")
+
+// Characters that end RSQL selectors and unquoted arguments
+const rsqlReserved = "\"'();,=!~<> \t"
+
+// RSQL comparison operators, FIQL style "=name=" ones included, eg: "price=gt=10" or "price>10".
+// Operators without an RSQL counterpart use custom ones, eg: "name=startswith=A".
+var rsqlOperators = map[string]FilterOperator{
+	"==":           FilterOperatorEqual,
+	"!=":           FilterOperatorNotEqual,
+	"<":            FilterOperatorLessThan,
+	"=lt=":         FilterOperatorLessThan,
+	"<=":           FilterOperatorLessThanOrEqual,
+	"=le=":         FilterOperatorLessThanOrEqual,
+	">":            FilterOperatorGreaterThan,
+	"=gt=":         FilterOperatorGreaterThan,
+	">=":           FilterOperatorGretherThanOrEqual,
+	"=ge=":         FilterOperatorGretherThanOrEqual,
+	"=in=":         FilterOperatorIn,
+	"=out=":        FilterOperatorNotIn,
+	"=between=":    FilterOperatorBetween,
+	"=like=":       FilterOperatorContains,
+	"=ilike=":      FilterOperatorContainsFold,
+	"=icase=":      FilterOperatorEqualFold,
+	"=startswith=": FilterOperatorStartsWith,
+	"=endswith=":   FilterOperatorEndsWith,
+	"=regex=":      FilterOperatorRegex,
+	rsqlIsNull:     FilterOperatorIsNull,
+}
+
+// Takes "true" for isNull and "false" for notNull, eg: "deletedAt=isnull=true"
+const rsqlIsNull = "=isnull="
+
+// Operators the encoder writes
+var rsqlEncodedOperators = map[FilterOperator]string{
+	FilterOperatorEqual:              "==",
+	FilterOperatorNotEqual:           "!=",
+	FilterOperatorLessThan:           "=lt=",
+	FilterOperatorLessThanOrEqual:    "=le=",
+	FilterOperatorGreaterThan:        "=gt=",
+	FilterOperatorGretherThanOrEqual: "=ge=",
+	FilterOperatorIn:                 "=in=",
+	FilterOperatorNotIn:              "=out=",
+	FilterOperatorBetween:            "=between=",
+	FilterOperatorContains:           "=like=",
+	FilterOperatorContainsFold:       "=ilike=",
+	FilterOperatorEqualFold:          "=icase=",
+	FilterOperatorStartsWith:         "=startswith=",
+	FilterOperatorEndsWith:           "=endswith=",
+	FilterOperatorRegex:              "=regex=",
+}
+
+// Operators RSQL negations are pushed down to, as RSQL has no NOT
+var rsqlNegatedOperators = map[FilterOperator]FilterOperator{
+	FilterOperatorEqual:              FilterOperatorNotEqual,
+	FilterOperatorNotEqual:           FilterOperatorEqual,
+	FilterOperatorLessThan:           FilterOperatorGretherThanOrEqual,
+	FilterOperatorGretherThanOrEqual: FilterOperatorLessThan,
+	FilterOperatorGreaterThan:        FilterOperatorLessThanOrEqual,
+	FilterOperatorLessThanOrEqual:    FilterOperatorGreaterThan,
+	FilterOperatorIn:                 FilterOperatorNotIn,
+	FilterOperatorNotIn:              FilterOperatorIn,
+	FilterOperatorIsNull:             FilterOperatorNotNull,
+	FilterOperatorNotNull:            FilterOperatorIsNull,
+}
+
+// Parses an RSQL/FIQL value into the same expression ParseFilterExpr builds for the native grammar,
+// eg: "name==foo;price=gt=10,status=in=(a,b)". ";" and "and" are AND, "," and "or" are OR, AND binds
+// tighter than OR and arguments may be quoted with '"' or "'", escaping with "\". Wildcards are not
+// interpreted, "=like=" and "=ilike=" match substrings instead.
+func ParseRSQL(value string, limits ExprLimits) (*FilterExpr, error) {
+	expr, errs := parseRSQL(value, limits, nil)
+	return expr, errs.orNil()
+}
+
+type rsqlParser struct {
+	value  string
+	pos    int
+	limits ExprLimits
+	schema *Schema
+	nodes  int
+	errs   ParseErrors
+}
+
+func parseRSQL(value string, limits ExprLimits, schema *Schema) (*FilterExpr, ParseErrors) {
+	if strings.TrimSpace(value) == "" {
+		return nil, ParseErrors{}
+	}
+
+	p := &rsqlParser{value: value, limits: limits.withDefaults(), schema: schema, errs: ParseErrors{}}
+
+	expr, err := p.or(0)
+	if err == nil {
+		p.skipSpaces()
+		if p.pos < len(p.value) {
+			err = p.error(p.pos, p.value[p.pos:], ErrUnexpectedToken)
+		}
+	}
+
+	if err != nil {
+		return nil, append(p.errs, err)
+	}
+
+	if len(p.errs) > 0 {
+		return nil, p.errs
+	}
+
+	return &expr, p.errs
+}
+
+func (p *rsqlParser) error(position int, text string, err error) *SegmentError {
+	return segment{text: text, position: position}.error(QueryKeyFilters, err)
+}
+
+func (p *rsqlParser) skipSpaces() {
+	for p.pos < len(p.value) && (p.value[p.pos] == ' ' || p.value[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// Consumes the symbol, or the keyword when surrounded by spaces, eg: ";" or " and "
+func (p *rsqlParser) separator(symbol byte, keyword string) bool {
+	start := p.pos
+	p.skipSpaces()
+
+	if p.pos < len(p.value) && p.value[p.pos] == symbol {
+		p.pos++
+		return true
+	}
+
+	if p.pos > start && strings.HasPrefix(p.value[p.pos:], keyword) {
+		end := p.pos + len(keyword)
+		if end < len(p.value) && (p.value[end] == ' ' || p.value[end] == '(') {
+			p.pos = end
+			return true
+		}
+	}
+
+	p.pos = start
+	return false
+}
+
+func (p *rsqlParser) node(start int) *SegmentError {
+	p.nodes++
+	if p.nodes > p.limits.MaxNodes {
+		return p.error(start, p.value[start:], ErrExprTooLarge)
+	}
+	return nil
+}
+
+func (p *rsqlParser) or(depth int) (FilterExpr, *SegmentError) {
+	return p.list(depth, ',', "or", Or, p.and)
+}
+
+func (p *rsqlParser) and(depth int) (FilterExpr, *SegmentError) {
+	return p.list(depth, ';', "and", And, p.operand)
+}
+
+// Parses operands separated by the symbol or keyword, combining them with combine when there is more than one
+func (p *rsqlParser) list(depth int, symbol byte, keyword string, combine func(...FilterExpr) FilterExpr, operand func(int) (FilterExpr, *SegmentError)) (FilterExpr, *SegmentError) {
+	start := p.pos
+	children := []FilterExpr{}
+
+	for {
+		child, err := operand(depth)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, child)
+
+		if !p.separator(symbol, keyword) {
+			break
+		}
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+
+	if err := p.node(start); err != nil {
+		return FilterExpr{}, err
+	}
+
+	return combine(children...), nil
+}
+
+func (p *rsqlParser) operand(depth int) (FilterExpr, *SegmentError) {
+	p.skipSpaces()
+	start := p.pos
+
+	if p.pos < len(p.value) && p.value[p.pos] == '(' {
+		if depth+1 > p.limits.MaxDepth {
+			return FilterExpr{}, p.error(start, p.value[start:], ErrExprTooDeep)
+		}
+
+		p.pos++
+		child, err := p.or(depth + 1)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+
+		p.skipSpaces()
+		if p.pos >= len(p.value) || p.value[p.pos] != ')' {
+			return FilterExpr{}, p.error(start, p.value[start:p.pos], ErrUnbalancedParens)
+		}
+		p.pos++
+		return child, nil
+	}
+
+	return p.comparison()
+}
+
+// Parses a comparison, eg: "price=gt=10", "status=in=(a,b)" or "name=='Smith, John'"
+func (p *rsqlParser) comparison() (FilterExpr, *SegmentError) {
+	start := p.pos
+	if err := p.node(start); err != nil {
+		return FilterExpr{}, err
+	}
+
+	selector := p.unreserved()
+	if selector == "" || !hasALetter(selector) {
+		return FilterExpr{}, p.error(start, p.value[start:], ErrInvalidField)
+	}
+
+	opStart := p.pos
+	operator := p.operator()
+	op, ok := rsqlOperators[operator]
+	if !ok || (op == FilterOperatorRegex && p.schema == nil) {
+		return FilterExpr{}, p.error(opStart, p.value[opStart:], fmt.Errorf("%w: %q", ErrUnknownOperator, operator))
+	}
+
+	arguments, grouped, err := p.arguments()
+	if err != nil {
+		return FilterExpr{}, err
+	}
+
+	f := Filter{Field: selector, Operation: op}
+	text := p.value[start:p.pos]
+
+	switch {
+	case operator == rsqlIsNull:
+		if grouped || (arguments[0] != "true" && arguments[0] != "false") {
+			return FilterExpr{}, p.error(start, text, fmt.Errorf("%w: %s takes true or false", ErrValueCount, operator))
+		}
+		if arguments[0] == "false" {
+			f.Operation = FilterOperatorNotNull
+		}
+	case op.Arity() == ArityTwo && len(arguments) != 2, op.Arity() == ArityOne && (grouped || len(arguments) != 1):
+		return FilterExpr{}, p.error(start, text, fmt.Errorf("%w: %q", ErrValueCount, operator))
+	case op.isList():
+		f.Value = JoinValues(arguments)
+	default:
+		f.Value = arguments[0]
+	}
+
+	if p.schema != nil {
+		coerced, coerceErr := p.schema.CoerceFilter(f)
+		if coerceErr != nil {
+			// keeps parsing to report every filter the schema rejects
+			p.errs = append(p.errs, p.error(start, text, coerceErr))
+			return Leaf(Filter{}), nil
+		}
+		f = coerced
+	}
+
+	return Leaf(f), nil
+}
+
+func (p *rsqlParser) unreserved() string {
+	start := p.pos
+	for p.pos < len(p.value) && !strings.ContainsRune(rsqlReserved, rune(p.value[p.pos])) {
+		p.pos++
+	}
+	return p.value[start:p.pos]
+}
+
+// Reads a comparison operator, eg: "==", "<=" or "=gt="
+func (p *rsqlParser) operator() string {
+	start := p.pos
+	rest := p.value[p.pos:]
+
+	for _, symbol := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, symbol) {
+			p.pos += len(symbol)
+			return symbol
+		}
+	}
+
+	if strings.HasPrefix(rest, "=") {
+		if end := strings.Index(rest[1:], "="); end != -1 {
+			p.pos += end + 2
+		}
+	}
+
+	return p.value[start:p.pos]
+}
+
+// Reads a single argument or a group of them, eg: "a" or "(a,'b c')"
+func (p *rsqlParser) arguments() ([]string, bool, *SegmentError) {
+	if p.pos < len(p.value) && p.value[p.pos] == '(' {
+		start := p.pos
+		p.pos++
+
+		arguments := []string{}
+		for {
+			p.skipSpaces()
+			argument, err := p.argument()
+			if err != nil {
+				return nil, true, err
+			}
+			arguments = append(arguments, argument)
+
+			p.skipSpaces()
+			if p.pos < len(p.value) && p.value[p.pos] == ',' {
+				p.pos++
+				continue
+			}
+			break
+		}
+
+		if p.pos >= len(p.value) || p.value[p.pos] != ')' {
+			return nil, true, p.error(start, p.value[start:p.pos], ErrUnbalancedParens)
+		}
+		p.pos++
+		return arguments, true, nil
+	}
+
+	argument, err := p.argument()
+	if err != nil {
+		return nil, false, err
+	}
+	return []string{argument}, false, nil
+}
+
+func (p *rsqlParser) argument() (string, *SegmentError) {
+	start := p.pos
+
+	if p.pos < len(p.value) && (p.value[p.pos] == '"' || p.value[p.pos] == '\'') {
+		quote := p.value[p.pos]
+		argument := strings.Builder{}
+
+		for p.pos++; p.pos < len(p.value); p.pos++ {
+			switch c := p.value[p.pos]; {
+			case c == '\\':
+				p.pos++
+				if p.pos >= len(p.value) {
+					return "", p.error(start, p.value[start:], ErrDanglingEscape)
+				}
+				argument.WriteByte(p.value[p.pos])
+			case c == quote:
+				p.pos++
+				return argument.String(), nil
+			default:
+				argument.WriteByte(c)
+			}
+		}
+
+		return "", p.error(start, p.value[start:], ErrUnterminatedQuote)
+	}
+
+	argument := p.unreserved()
+	if argument == "" {
+		return "", p.error(start, p.value[start:], ErrEmptyValue)
+	}
+	return argument, nil
+}
+
+// Encodes the expression as RSQL, eg: "name==foo;(price=gt=10,status=in=(a,b))".
+// As RSQL has no NOT, negations are pushed down to the filters, which fails for operators
+// that have no negated counterpart, eg: contains. Flat filters can be encoded with
+// EncodeRSQL(q.FilterExpr()), to forward a query to a backend that expects RSQL.
+func EncodeRSQL(e FilterExpr) (string, error) {
+	e, err := withoutNot(e)
+	if err != nil {
+		return "", err
+	}
+	return encodeRSQL(e)
+}
+
+func encodeRSQL(e FilterExpr) (string, error) {
+	if e.Kind == FilterExprLeaf {
+		return encodeRSQLFilter(*e.Filter)
+	}
+
+	sep := ";"
+	if e.Kind == FilterExprOr {
+		sep = ","
+	}
+
+	operands := make([]string, 0, len(e.Children))
+	for _, c := range e.Children {
+		operand, err := encodeRSQL(c)
+		if err != nil {
+			return "", err
+		}
+
+		if c.Kind == FilterExprOr && e.Kind != FilterExprOr {
+			operand = "(" + operand + ")"
+		}
+		operands = append(operands, operand)
+	}
+
+	return strings.Join(operands, sep), nil
+}
+
+// Returns the same expression without NOT, pushing negations down to the filters
+func withoutNot(e FilterExpr) (FilterExpr, error) {
+	switch e.Kind {
+	case FilterExprLeaf:
+		return e, nil
+	case FilterExprNot:
+		return negateRSQL(e.Children[0])
+	}
+
+	children := make([]FilterExpr, 0, len(e.Children))
+	for _, c := range e.Children {
+		child, err := withoutNot(c)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, child)
+	}
+
+	return FilterExpr{Kind: e.Kind, Children: children}, nil
+}
+
+// Applies De Morgan's laws and negates the filter operators, eg: NOT (a < 1 OR b IN (x, y)) -> a >= 1 AND b NOT IN (x, y)
+func negateRSQL(e FilterExpr) (FilterExpr, error) {
+	switch e.Kind {
+	case FilterExprLeaf:
+		f := *e.Filter
+
+		if f.Operation == FilterOperatorBetween {
+			values := f.Values()
+			if len(values) != 2 {
+				return FilterExpr{}, fmt.Errorf("%w: %q takes 2", ErrValueCount, f.Operation)
+			}
+			return Or(
+				Leaf(Filter{Field: f.Field, Operation: FilterOperatorLessThan, Value: values[0]}),
+				Leaf(Filter{Field: f.Field, Operation: FilterOperatorGreaterThan, Value: values[1]}),
+			), nil
+		}
+
+		op, ok := rsqlNegatedOperators[f.Operation]
+		if !ok {
+			return FilterExpr{}, fmt.Errorf("%w: negated %q", ErrNotExpressible, f.Operation)
+		}
+		f.Operation = op
+		return Leaf(f), nil
+	case FilterExprNot:
+		return withoutNot(e.Children[0])
+	}
+
+	children := make([]FilterExpr, 0, len(e.Children))
+	for _, c := range e.Children {
+		negated, err := negateRSQL(c)
+		if err != nil {
+			return FilterExpr{}, err
+		}
+		children = append(children, negated)
+	}
+
+	if e.Kind == FilterExprAnd {
+		return Or(children...), nil
+	}
+	return And(children...), nil
+}
+
+func encodeRSQLFilter(f Filter) (string, error) {
+	if f.Field == "" || strings.ContainsAny(f.Field, rsqlReserved) {
+		return "", fmt.Errorf("%w: field %q", ErrNotExpressible, f.Field)
+	}
+
+	switch f.Operation {
+	case FilterOperatorIsNull:
+		return f.Field + rsqlIsNull + "true", nil
+	case FilterOperatorNotNull:
+		return f.Field + rsqlIsNull + "false", nil
+	}
+
+	operator, ok := rsqlEncodedOperators[f.Operation]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownOperator, f.Operation)
+	}
+
+	if !f.Operation.isList() {
+		return f.Field + operator + encodeRSQLArgument(f.Value), nil
+	}
+
+	arguments := []string{}
+	for _, v := range f.Values() {
+		arguments = append(arguments, encodeRSQLArgument(v))
+	}
+	return f.Field + operator + "(" + strings.Join(arguments, ",") + ")", nil
+}
+
+// Quotes arguments that are empty or have reserved characters, eg: "Smith, John" -> "\"Smith, John\""
+func encodeRSQLArgument(v string) string {
+	if v != "" && !strings.ContainsAny(v, rsqlReserved+"\\") {
+		return v
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+}
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRSQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		native   string // same expression on the native grammar
+		wantErrs []*SegmentError
+	}{
+		{
+			name:   "should parse and binding tighter than or",
+			value:  "name==foo;price=gt=10,status=in=(a,b)",
+			native: "name[eq]foo,price[gt]10|status[in]a;b",
+		},
+		{
+			name:   "should parse groups and keywords",
+			value:  "name==foo and (price>10 or price<=5)",
+			native: "name[eq]foo,(price[gt]10|price[le]5)",
+		},
+		{
+			name:   "should parse quoted arguments",
+			value:  `name=="Smith, John";title=='say "hi"';path==C:\dir;tags=out=("a;b",'c\'d')`,
+			native: `name[eq]Smith\, John,title[eq]say \"hi\",path[eq]C:\\dir,tags[nin]a\;b;c'd`,
+		},
+		{
+			name:   "should parse custom operators",
+			value:  "price=between=(1,2);deletedAt=isnull=true;email=isnull=false;name=like=a;name=ilike=B;name=icase=c;name=startswith=d;name=endswith=e",
+			native: "price[between]1;2,deletedAt[isNull],email[notNull],name[contains]a,name[icontains]B,name[ieq]c,name[startsWith]d,name[endsWith]e",
+		},
+		{
+			name:  "should report unknown operators",
+			value: "name=foo=bar",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "=foo=bar", Position: 4, Err: ErrUnknownOperator},
+			},
+		},
+		{
+			name:  "should report wrong amount of arguments",
+			value: "price=between=(1,2,3)",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "price=between=(1,2,3)", Position: 0, Err: ErrValueCount},
+			},
+		},
+		{
+			name:  "should report unbalanced parens",
+			value: "(name==a,price>1",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "(name==a,price>1", Position: 0, Err: ErrUnbalancedParens},
+			},
+		},
+		{
+			name:  "should report unterminated quotes",
+			value: `name=="abc`,
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: `"abc`, Position: 6, Err: ErrUnterminatedQuote},
+			},
+		},
+		{
+			name:  "should report regex when parsed without a schema",
+			value: "name=regex=a.*",
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "=regex=a.*", Position: 4, Err: ErrUnknownOperator},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRSQL(tt.value, ExprLimits{})
+			assertSegmentErrors(t, tt.wantErrs, err)
+
+			if tt.native != "" {
+				want, err := ParseFilterExpr(tt.native, ExprLimits{})
+				assert.Nil(t, err)
+				assert.True(t, reflect.DeepEqual(want, got), "got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeRSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		native  string
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "should group or inside and",
+			native: `(status[eq]open|assignee[eq]me),name[eq]Smith\, John,tags[in]a\;b;c`,
+			want:   `(status==open,assignee==me);name=="Smith, John";tags=in=("a;b",c)`,
+		},
+		{
+			name:   "should push negations down",
+			native: "!(price[lt]1|tags[in]x;y),!deletedAt[isNull],!price[between]1;2",
+			want:   "price=ge=1;tags=out=(x,y);deletedAt=isnull=false;(price=lt=1,price=gt=2)",
+		},
+		{
+			name:    "should fail on negations without counterpart",
+			native:  "!name[contains]a",
+			wantErr: ErrNotExpressible,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := ParseFilterExpr(tt.native, ExprLimits{})
+			assert.Nil(t, err)
+
+			got, err := EncodeRSQL(*expr)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+
+			parsed, err := ParseRSQL(got, ExprLimits{})
+			assert.Nil(t, err)
+			if reEncoded, err := EncodeRSQL(*parsed); assert.Nil(t, err) {
+				assert.Equal(t, got, reEncoded)
+			}
+		})
+	}
+}
+
+func TestParseWithSyntax(t *testing.T) {
+	expect := assert.New(t)
+
+	got, err := parse(url.Values{"filters": {"price=gt=10;name==a"}}, Options{Syntax: SyntaxRSQL})
+	expect.Nil(err)
+	expect.Equal([]Filter{
+		{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"},
+		{Field: "name", Operation: FilterOperatorEqual, Value: "a"},
+	}, got.Filters)
+
+	got, err = ParseValues(url.Values{"$filter": {"price gt 10"}, "$top": {"5"}}, Options{Syntax: SyntaxOData})
+	expect.Nil(err)
+	expect.Equal([]Filter{{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10"}}, got.Filters)
+	expect.Equal(5, got.Pagination.Limit)
+
+	encoded, err := EncodeRSQL(got.FilterExpr())
+	expect.Nil(err)
+	expect.Equal("price=gt=10", encoded)
+}
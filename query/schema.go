@@ -0,0 +1,118 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrFieldNotFilterable = errors.New("field is not filterable")
+	ErrOperatorNotAllowed = errors.New("operator is not allowed for field")
+	ErrFieldNotSortable   = errors.New("field is not sortable")
+)
+
+// Declares how a single field of an endpoint may be queried
+type Field struct {
+	Operators  []FilterOperator // operators the field accepts on filters, the field is not filterable when empty
+	Sortable   bool             // if true, the field may be used on order
+	Selectable bool             // if true, the field and its nested paths may be requested on fields
+	Type       FieldType        // type filter values are converted to, defaults to FieldTypeString
+	Enum       []string         // accepted values when Type is FieldTypeEnum
+}
+
+// Declares, per endpoint, which fields may be filtered, sorted and selected, and which relations may be included.
+// Filters, orders, fields and includes outside the schema are rejected.
+type Schema struct {
+	Fields          map[string]Field // keyed by the field name as sent by the client, eg: "price"
+	Includes        []string         // relation paths that may be included, parents included, eg: "items.product"
+	MaxIncludeDepth int              // maximum amount of relations on an include path, defaults to DefaultMaxIncludeDepth
+}
+
+func (f *Field) allowsOperator(op FilterOperator) bool {
+	for _, o := range f.Operators {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks that the filter field is declared as filterable and accepts the filter operator
+func (s *Schema) ValidateFilter(filter Filter) error {
+	field, ok := s.Fields[filter.Field]
+	if !ok || len(field.Operators) == 0 {
+		return fmt.Errorf("%w: %q", ErrFieldNotFilterable, filter.Field)
+	}
+
+	if !field.allowsOperator(filter.Operation) {
+		return fmt.Errorf("%w: %q does not accept %q", ErrOperatorNotAllowed, filter.Field, filter.Operation)
+	}
+
+	if !field.Type.Supports(filter.Operation) {
+		return fmt.Errorf("%w: %q can't be used on %s field %q", ErrOperatorUnsupported, filter.Operation, field.Type, filter.Field)
+	}
+
+	return nil
+}
+
+// Validates the filter and converts its value to the field type, setting Filter.Typed
+func (s *Schema) CoerceFilter(filter Filter) (Filter, error) {
+	if err := s.ValidateFilter(filter); err != nil {
+		return Filter{}, err
+	}
+
+	field := s.Fields[filter.Field]
+	typed, err := field.coerce(filter)
+	if err != nil {
+		return Filter{}, fmt.Errorf("%w: %q for %s field %q: %v", ErrInvalidFilterValue, filter.Value, field.Type, filter.Field, err)
+	}
+
+	filter.Typed = typed
+	return filter, nil
+}
+
+// Checks that the order field is declared as sortable
+func (s *Schema) ValidateOrder(order Order) error {
+	field, ok := s.Fields[order.Field]
+	if !ok || !field.Sortable {
+		return fmt.Errorf("%w: %q", ErrFieldNotSortable, order.Field)
+	}
+
+	return nil
+}
+
+// Same as GetFilterFromQuery, but returns an error when a filter is not allowed by the schema
+// or its value can't be converted to the field type
+func GetFilterFromQueryWithSchema(c *fiber.Ctx, schema *Schema) ([]Filter, error) {
+	queryParams := queryArgsToValues(c)
+	return getFilterFromQueryWithSchema(queryParams, schema)
+}
+
+func getFilterFromQueryWithSchema(queryParams url.Values, schema *Schema) ([]Filter, error) {
+	values, ok := queryParams[string(QueryKeyFilters)]
+	if !ok {
+		return []Filter{}, nil
+	}
+
+	filters, errs := parseFilters(values, schema, false)
+	return filters, errs.orNil()
+}
+
+// Same as GetOrderFromQuery, but returns an error when an order is not allowed by the schema
+func GetOrderFromQueryWithSchema(c *fiber.Ctx, schema *Schema) ([]Order, error) {
+	queryParams := queryArgsToValues(c)
+	return getOrderFromQueryWithSchema(queryParams, schema)
+}
+
+func getOrderFromQueryWithSchema(queryParams url.Values, schema *Schema) ([]Order, error) {
+	values, ok := queryParams[string(QueryKeyOrder)]
+	if !ok {
+		return []Order{}, nil
+	}
+
+	orders, errs := parseOrders(values, schema, false)
+	return orders, errs.orNil()
+}
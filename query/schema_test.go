@@ -0,0 +1,164 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSchema = &Schema{
+	Fields: map[string]Field{
+		"price": {
+			Operators: []FilterOperator{FilterOperatorGreaterThan, FilterOperatorLessThan, FilterOperatorEqual},
+			Sortable:  true,
+			Type:      FieldTypeInt,
+		},
+		"name": {
+			Operators: []FilterOperator{FilterOperatorContains},
+		},
+		"createdAt": {
+			Sortable: true,
+		},
+	},
+}
+
+func TestGetFilterFromQueryWithSchema(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []Filter
+		wantErr error
+	}{
+		{
+			name: "should return empty slice when query params has no filters",
+			args: args{queryParams: url.Values{}},
+			want: []Filter{},
+		},
+		{
+			name: "should return Filter slice when filters are allowed by the schema",
+			args: args{queryParams: url.Values{
+				"filters": {"price[gt]10,name[contains]shoe"},
+			}},
+			want: []Filter{
+				{
+					Field:     "price",
+					Operation: FilterOperatorGreaterThan,
+					Value:     "10",
+					Typed:     int64(10),
+				},
+				{
+					Field:     "name",
+					Operation: FilterOperatorContains,
+					Value:     "shoe",
+					Typed:     "shoe",
+				},
+			},
+		},
+		{
+			name: "should return error when field is not in the schema",
+			args: args{queryParams: url.Values{
+				"filters": {"price[gt]10,password[eq]123"},
+			}},
+			wantErr: ErrFieldNotFilterable,
+		},
+		{
+			name: "should return error when field is not filterable",
+			args: args{queryParams: url.Values{
+				"filters": {"createdAt[eq]2022"},
+			}},
+			wantErr: ErrFieldNotFilterable,
+		},
+		{
+			name: "should return error when operator is not allowed for field",
+			args: args{queryParams: url.Values{
+				"filters": {"name[eq]shoe"},
+			}},
+			wantErr: ErrOperatorNotAllowed,
+		},
+		{
+			name: "should return error when value can't be converted to the field type",
+			args: args{queryParams: url.Values{
+				"filters": {"price[gt]abc"},
+			}},
+			wantErr: ErrInvalidFilterValue,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getFilterFromQueryWithSchema(tt.args.queryParams, testSchema)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestGetOrderFromQueryWithSchema(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []Order
+		wantErr error
+	}{
+		{
+			name: "should return empty slice when query params has no order",
+			args: args{queryParams: url.Values{}},
+			want: []Order{},
+		},
+		{
+			name: "should return Order slice when fields are sortable",
+			args: args{queryParams: url.Values{
+				"order": {"price:asc,createdAt:desc"},
+			}},
+			want: []Order{
+				{
+					Field: "price",
+					Asc:   true,
+				},
+				{
+					Field: "createdAt",
+					Asc:   false,
+				},
+			},
+		},
+		{
+			name: "should return error when field is not sortable",
+			args: args{queryParams: url.Values{
+				"order": {"price:asc,name:desc"},
+			}},
+			wantErr: ErrFieldNotSortable,
+		},
+		{
+			name: "should return error when field is not in the schema",
+			args: args{queryParams: url.Values{
+				"order": {"password:asc"},
+			}},
+			wantErr: ErrFieldNotSortable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getOrderFromQueryWithSchema(tt.args.queryParams, testSchema)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
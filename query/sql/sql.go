@@ -0,0 +1,402 @@
+// Package sql renders parsed query filters, orders and pagination as parameterized SQL clauses.
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+)
+
+var (
+	ErrUnknownField        = errors.New("field has no column")
+	ErrUnsupportedOperator = errors.New("operator is not supported")
+)
+
+type Dialect int
+
+const (
+	DialectPostgres Dialect = iota // $1, $2...
+	DialectMySQL                   // ?
+	DialectSQLite                  // ?
+)
+
+// Character used to escape LIKE wildcards, chosen as it needs no escaping in any dialect string literal
+const likeEscape = "!"
+
+var likeReplacer = strings.NewReplacer(likeEscape, likeEscape+likeEscape, "%", likeEscape+"%", "_", likeEscape+"_")
+
+// Builds SQL clauses from parsed queries. Values are always passed as arguments and
+// only columns present on Columns are ever written to the SQL. Queries rewritten by
+// query.Aliases already hold column names, so they are built with Columns: aliases.Names().
+type Builder struct {
+	Dialect Dialect
+	Columns map[string]string // maps the field names sent by clients to columns, eg: "createdAt" -> "p.created_at"
+}
+
+// Clauses to append after a SELECT ... FROM statement, each one empty when there is nothing to render
+type Clauses struct {
+	Where   string        // eg: "WHERE price > $1 AND name LIKE $2 ESCAPE '!'"
+	OrderBy string        // eg: "ORDER BY price ASC, name DESC"
+	Limit   string        // eg: "LIMIT $3 OFFSET $4"
+	Args    []interface{} // arguments for the placeholders, in order
+}
+
+// Joins the non empty clauses, eg: "WHERE price > $1 ORDER BY price ASC LIMIT $2 OFFSET $3"
+func (c Clauses) String() string {
+	parts := []string{}
+	for _, p := range []string{c.Where, c.OrderBy, c.Limit} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type statement struct {
+	dialect Dialect
+	args    []interface{}
+}
+
+// Registers the argument and returns its placeholder
+func (s *statement) param(v interface{}) string {
+	s.args = append(s.args, v)
+
+	if s.dialect == DialectPostgres {
+		return "$" + strconv.Itoa(len(s.args))
+	}
+	return "?"
+}
+
+// Renders the WHERE, ORDER BY and LIMIT clauses sharing the same argument list.
+// When the pagination has a cursor, its keyset predicate is added to the WHERE clause
+// and the ORDER BY follows Cursor.FetchOrders.
+func (b Builder) Build(filters []query.Filter, orders []query.Order, pagination query.Paginable) (Clauses, error) {
+	s := &statement{dialect: b.Dialect}
+
+	conditions, err := b.conditions(s, filters)
+	if err != nil {
+		return Clauses{}, err
+	}
+
+	return b.build(s, conditions, orders, pagination)
+}
+
+// Same as Build, but renders the query filters expression when it has one
+func (b Builder) BuildQuery(q query.Query) (Clauses, error) {
+	if q.Expr == nil {
+		return b.Build(q.Filters, q.Orders, q.Pagination)
+	}
+
+	if err := q.Expr.Validate(); err != nil {
+		return Clauses{}, err
+	}
+
+	s := &statement{dialect: b.Dialect}
+
+	condition, err := b.expr(s, *q.Expr)
+	if err != nil {
+		return Clauses{}, err
+	}
+
+	return b.build(s, []string{condition}, q.Orders, q.Pagination)
+}
+
+func (b Builder) build(s *statement, conditions []string, orders []query.Order, pagination query.Paginable) (Clauses, error) {
+	if pagination.Cursor != nil {
+		if !pagination.Cursor.Matches(orders) {
+			return Clauses{}, query.ErrCursorMismatch
+		}
+
+		keyset, err := b.keyset(s, pagination.Cursor)
+		if err != nil {
+			return Clauses{}, err
+		}
+
+		conditions = append(conditions, keyset)
+		orders = pagination.Cursor.FetchOrders()
+	}
+
+	orderBy, err := b.OrderBy(orders)
+	if err != nil {
+		return Clauses{}, err
+	}
+
+	return Clauses{
+		Where:   where(conditions),
+		OrderBy: orderBy,
+		Limit:   b.limit(s, pagination),
+		Args:    s.args,
+	}, nil
+}
+
+// Renders the expression as a WHERE clause, eg: "WHERE ((status = $1 OR assignee = $2) AND NOT (deleted = $3))"
+func (b Builder) WhereExpr(expr query.FilterExpr) (string, []interface{}, error) {
+	if err := expr.Validate(); err != nil {
+		return "", nil, err
+	}
+
+	s := &statement{dialect: b.Dialect}
+
+	condition, err := b.expr(s, expr)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return where([]string{condition}), s.args, nil
+}
+
+func (b Builder) expr(s *statement, e query.FilterExpr) (string, error) {
+	switch e.Kind {
+	case query.FilterExprLeaf:
+		return b.condition(s, *e.Filter)
+	case query.FilterExprNot:
+		c, err := b.expr(s, e.Children[0])
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + c + ")", nil
+	}
+
+	join := " AND "
+	if e.Kind == query.FilterExprOr {
+		join = " OR "
+	}
+
+	conditions := make([]string, 0, len(e.Children))
+	for _, child := range e.Children {
+		c, err := b.expr(s, child)
+		if err != nil {
+			return "", err
+		}
+
+		conditions = append(conditions, c)
+	}
+
+	return "(" + strings.Join(conditions, join) + ")", nil
+}
+
+// Renders the filters as a WHERE clause joined by AND, eg: "WHERE price > $1"
+func (b Builder) Where(filters []query.Filter) (string, []interface{}, error) {
+	s := &statement{dialect: b.Dialect}
+
+	conditions, err := b.conditions(s, filters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return where(conditions), s.args, nil
+}
+
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+func (b Builder) conditions(s *statement, filters []query.Filter) ([]string, error) {
+	conditions := make([]string, 0, len(filters))
+
+	for _, f := range filters {
+		c, err := b.condition(s, f)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions = append(conditions, c)
+	}
+
+	return conditions, nil
+}
+
+// Renders the predicate selecting the rows after the cursor,
+// eg: "(price > $1 OR (price = $2 AND id > $3))"
+func (b Builder) Keyset(cursor *query.Cursor) (string, []interface{}, error) {
+	s := &statement{dialect: b.Dialect}
+
+	keyset, err := b.keyset(s, cursor)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyset, s.args, nil
+}
+
+func (b Builder) keyset(s *statement, cursor *query.Cursor) (string, error) {
+	orders := cursor.FetchOrders()
+	if len(orders) == 0 || len(orders) != len(cursor.Values) {
+		return "", query.ErrInvalidCursor
+	}
+
+	columns := make([]string, 0, len(orders))
+	for _, o := range orders {
+		column, err := b.column(o.Field)
+		if err != nil {
+			return "", err
+		}
+
+		columns = append(columns, column)
+	}
+
+	alternatives := make([]string, 0, len(orders))
+	for i, o := range orders {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, columns[j]+" = "+s.param(cursor.Values[j]))
+		}
+
+		comparison := " < "
+		if o.Asc {
+			comparison = " > "
+		}
+		parts = append(parts, columns[i]+comparison+s.param(cursor.Values[i]))
+
+		alternative := strings.Join(parts, " AND ")
+		if len(parts) > 1 {
+			alternative = "(" + alternative + ")"
+		}
+
+		alternatives = append(alternatives, alternative)
+	}
+
+	return "(" + strings.Join(alternatives, " OR ") + ")", nil
+}
+
+func (b Builder) condition(s *statement, f query.Filter) (string, error) {
+	column, err := b.column(f.Field)
+	if err != nil {
+		return "", err
+	}
+
+	switch f.Operation {
+	case query.FilterOperatorEqual:
+		return column + " = " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorNotEqual:
+		return column + " <> " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorLessThan:
+		return column + " < " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorLessThanOrEqual:
+		return column + " <= " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorGreaterThan:
+		return column + " > " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorGretherThanOrEqual:
+		return column + " >= " + s.param(f.TypedValue()), nil
+	case query.FilterOperatorIn:
+		return column + " IN (" + list(s, f.TypedValues()) + ")", nil
+	case query.FilterOperatorNotIn:
+		return column + " NOT IN (" + list(s, f.TypedValues()) + ")", nil
+	case query.FilterOperatorBetween:
+		values := f.TypedValues()
+		if len(values) != 2 {
+			return "", fmt.Errorf("%w: %q takes 2", query.ErrValueCount, f.Operation)
+		}
+		return column + " BETWEEN " + s.param(values[0]) + " AND " + s.param(values[1]), nil
+	case query.FilterOperatorIsNull:
+		return column + " IS NULL", nil
+	case query.FilterOperatorNotNull:
+		return column + " IS NOT NULL", nil
+	case query.FilterOperatorStartsWith:
+		return like(s, column, likeReplacer.Replace(f.Value)+"%"), nil
+	case query.FilterOperatorEndsWith:
+		return like(s, column, "%"+likeReplacer.Replace(f.Value)), nil
+	case query.FilterOperatorContains:
+		return like(s, column, "%"+likeReplacer.Replace(f.Value)+"%"), nil
+	case query.FilterOperatorEqualFold:
+		return "LOWER(" + column + ") = LOWER(" + s.param(f.Value) + ")", nil
+	case query.FilterOperatorContainsFold:
+		return "LOWER(" + column + ") LIKE LOWER(" + s.param("%"+likeReplacer.Replace(f.Value)+"%") + ") ESCAPE '" + likeEscape + "'", nil
+	case query.FilterOperatorRegex:
+		return b.regex(s, column, f.Value), nil
+	}
+
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedOperator, f.Operation)
+}
+
+func list(s *statement, values []interface{}) string {
+	placeholders := make([]string, 0, len(values))
+	for _, v := range values {
+		placeholders = append(placeholders, s.param(v))
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+func like(s *statement, column, pattern string) string {
+	return column + " LIKE " + s.param(pattern) + " ESCAPE '" + likeEscape + "'"
+}
+
+// SQLite has no REGEXP implementation by default, the connection must register one
+func (b Builder) regex(s *statement, column, pattern string) string {
+	if b.Dialect == DialectPostgres {
+		return column + " ~ " + s.param(pattern)
+	}
+	return column + " REGEXP " + s.param(pattern)
+}
+
+// Renders the column list of a SELECT for the requested fields, eg: "id, p.name", or "*" when there are none
+func (b Builder) Select(fields []string) (string, error) {
+	if len(fields) == 0 {
+		return "*", nil
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, f := range fields {
+		column, err := b.column(f)
+		if err != nil {
+			return "", err
+		}
+
+		columns = append(columns, column)
+	}
+
+	return strings.Join(columns, ", "), nil
+}
+
+// Renders the orders as an ORDER BY clause, eg: "ORDER BY price ASC, name DESC"
+func (b Builder) OrderBy(orders []query.Order) (string, error) {
+	if len(orders) == 0 {
+		return "", nil
+	}
+
+	sorts := make([]string, 0, len(orders))
+	for _, o := range orders {
+		column, err := b.column(o.Field)
+		if err != nil {
+			return "", err
+		}
+
+		direction := "DESC"
+		if o.Asc {
+			direction = "ASC"
+		}
+
+		sorts = append(sorts, column+" "+direction)
+	}
+
+	return "ORDER BY " + strings.Join(sorts, ", "), nil
+}
+
+// Renders the pagination as a LIMIT clause, eg: "LIMIT $1 OFFSET $2", without OFFSET when paginating by cursor
+func (b Builder) Limit(pagination query.Paginable) (string, []interface{}) {
+	s := &statement{dialect: b.Dialect}
+	return b.limit(s, pagination), s.args
+}
+
+func (b Builder) limit(s *statement, pagination query.Paginable) string {
+	if pagination.Cursor != nil {
+		return "LIMIT " + s.param(pagination.Limit)
+	}
+
+	return "LIMIT " + s.param(pagination.Limit) + " OFFSET " + s.param(pagination.Offset)
+}
+
+func (b Builder) column(field string) (string, error) {
+	column, ok := b.Columns[field]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownField, field)
+	}
+	return column, nil
+}
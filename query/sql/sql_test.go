@@ -0,0 +1,389 @@
+package sql
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/criticalmassbr/gateway-commons/query"
+	"github.com/stretchr/testify/assert"
+)
+
+var testColumns = map[string]string{
+	"price":     "price",
+	"name":      "p.name",
+	"tags":      "tag",
+	"createdAt": "created_at",
+}
+
+func TestBuilderBuild(t *testing.T) {
+	type args struct {
+		dialect    Dialect
+		filters    []query.Filter
+		orders     []query.Order
+		pagination query.Paginable
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     string
+		wantArgs []interface{}
+		wantErr  error
+	}{
+		{
+			name: "should only render limit when there are no filters nor orders",
+			args: args{
+				dialect:    DialectPostgres,
+				pagination: query.Paginable{Limit: 10, Offset: 0},
+			},
+			want:     "LIMIT $1 OFFSET $2",
+			wantArgs: []interface{}{10, 0},
+		},
+		{
+			name: "should render numbered placeholders for postgres",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorGreaterThan, Value: "10", Typed: int64(10)},
+					{Field: "name", Operation: query.FilterOperatorEqual, Value: "shoe"},
+				},
+				orders:     []query.Order{{Field: "createdAt", Asc: false}, {Field: "price", Asc: true}},
+				pagination: query.Paginable{Limit: 20, Offset: 40},
+			},
+			want:     "WHERE price > $1 AND p.name = $2 ORDER BY created_at DESC, price ASC LIMIT $3 OFFSET $4",
+			wantArgs: []interface{}{int64(10), "shoe", 20, 40},
+		},
+		{
+			name: "should render question mark placeholders for mysql",
+			args: args{
+				dialect: DialectMySQL,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorLessThanOrEqual, Value: "10"},
+					{Field: "price", Operation: query.FilterOperatorNotEqual, Value: "5"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE price <= ? AND price <> ? LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{"10", "5", 10, 0},
+		},
+		{
+			name: "should render question mark placeholders for sqlite",
+			args: args{
+				dialect: DialectSQLite,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorGretherThanOrEqual, Value: "1"},
+					{Field: "price", Operation: query.FilterOperatorLessThan, Value: "9"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE price >= ? AND price < ? LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{"1", "9", 10, 0},
+		},
+		{
+			name: "should split in values on the array separator",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "tags", Operation: query.FilterOperatorIn, Value: "a;b;c"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE tag IN ($1, $2, $3) LIMIT $4 OFFSET $5",
+			wantArgs: []interface{}{"a", "b", "c", 10, 0},
+		},
+		{
+			name: "should use typed in values",
+			args: args{
+				dialect: DialectMySQL,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorIn, Value: "1;2", Typed: []int64{1, 2}},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE price IN (?, ?) LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{int64(1), int64(2), 10, 0},
+		},
+		{
+			name: "should escape like wildcards",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "name", Operation: query.FilterOperatorStartsWith, Value: "50%"},
+					{Field: "name", Operation: query.FilterOperatorEndsWith, Value: "a_b"},
+					{Field: "name", Operation: query.FilterOperatorContains, Value: "wow!"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE p.name LIKE $1 ESCAPE '!' AND p.name LIKE $2 ESCAPE '!' AND p.name LIKE $3 ESCAPE '!' LIMIT $4 OFFSET $5",
+			wantArgs: []interface{}{"50!%%", "%a!_b", "%wow!!%", 10, 0},
+		},
+		{
+			name: "should render keyset predicate and skip offset when paginating by cursor",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "name", Operation: query.FilterOperatorEqual, Value: "shoe"},
+				},
+				orders: []query.Order{{Field: "price", Asc: false}, {Field: "createdAt", Asc: true}},
+				pagination: query.Paginable{Limit: 10, Cursor: &query.Cursor{
+					Orders: []query.Order{{Field: "price", Asc: false}, {Field: "createdAt", Asc: true}},
+					Values: []interface{}{int64(10), "2022"},
+				}},
+			},
+			want:     "WHERE p.name = $1 AND (price < $2 OR (price = $3 AND created_at > $4)) ORDER BY price DESC, created_at ASC LIMIT $5",
+			wantArgs: []interface{}{"shoe", int64(10), int64(10), "2022", 10},
+		},
+		{
+			name: "should reverse keyset predicate and order when cursor goes backward",
+			args: args{
+				dialect: DialectMySQL,
+				orders:  []query.Order{{Field: "price", Asc: true}},
+				pagination: query.Paginable{Limit: 10, Cursor: &query.Cursor{
+					Orders:   []query.Order{{Field: "price", Asc: true}},
+					Values:   []interface{}{int64(10)},
+					Backward: true,
+				}},
+			},
+			want:     "WHERE (price < ?) ORDER BY price DESC LIMIT ?",
+			wantArgs: []interface{}{int64(10), 10},
+		},
+		{
+			name: "should return error when cursor does not match order",
+			args: args{
+				dialect: DialectPostgres,
+				orders:  []query.Order{{Field: "price", Asc: false}},
+				pagination: query.Paginable{Limit: 10, Cursor: &query.Cursor{
+					Orders: []query.Order{{Field: "price", Asc: true}},
+					Values: []interface{}{int64(10)},
+				}},
+			},
+			wantErr: query.ErrCursorMismatch,
+		},
+		{
+			name: "should render range, exclusion, null and case insensitive operators",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorBetween, Value: "1;2", Typed: []int64{1, 2}},
+					{Field: "tags", Operation: query.FilterOperatorNotIn, Value: "a;b"},
+					{Field: "createdAt", Operation: query.FilterOperatorIsNull},
+					{Field: "createdAt", Operation: query.FilterOperatorNotNull},
+					{Field: "name", Operation: query.FilterOperatorEqualFold, Value: "Shoe"},
+					{Field: "name", Operation: query.FilterOperatorContainsFold, Value: "10%"},
+					{Field: "name", Operation: query.FilterOperatorRegex, Value: "^a"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want: "WHERE price BETWEEN $1 AND $2 AND tag NOT IN ($3, $4) AND created_at IS NULL AND created_at IS NOT NULL" +
+				" AND LOWER(p.name) = LOWER($5) AND LOWER(p.name) LIKE LOWER($6) ESCAPE '!' AND p.name ~ $7 LIMIT $8 OFFSET $9",
+			wantArgs: []interface{}{int64(1), int64(2), "a", "b", "Shoe", "%10!%%", "^a", 10, 0},
+		},
+		{
+			name: "should render regex for mysql",
+			args: args{
+				dialect: DialectMySQL,
+				filters: []query.Filter{
+					{Field: "name", Operation: query.FilterOperatorRegex, Value: "^a"},
+				},
+				pagination: query.Paginable{Limit: 10},
+			},
+			want:     "WHERE p.name REGEXP ? LIMIT ? OFFSET ?",
+			wantArgs: []interface{}{"^a", 10, 0},
+		},
+		{
+			name: "should return error when between has a single value",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperatorBetween, Value: "1"},
+				},
+			},
+			wantErr: query.ErrValueCount,
+		},
+		{
+			name: "should return error when filter field has no column",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "password", Operation: query.FilterOperatorEqual, Value: "1"},
+				},
+			},
+			wantErr: ErrUnknownField,
+		},
+		{
+			name: "should return error when order field has no column",
+			args: args{
+				dialect: DialectPostgres,
+				orders:  []query.Order{{Field: "1; DROP TABLE users", Asc: true}},
+			},
+			wantErr: ErrUnknownField,
+		},
+		{
+			name: "should return error when operator is not supported",
+			args: args{
+				dialect: DialectPostgres,
+				filters: []query.Filter{
+					{Field: "price", Operation: query.FilterOperator("like"), Value: "1"},
+				},
+			},
+			wantErr: ErrUnsupportedOperator,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := Builder{Dialect: tt.args.dialect, Columns: testColumns}
+			got, err := b.Build(tt.args.filters, tt.args.orders, tt.args.pagination)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got.String())
+			assert.True(t, reflect.DeepEqual(tt.wantArgs, got.Args), "got: %v, want: %v", got.Args, tt.wantArgs)
+		})
+	}
+}
+
+func TestBuilderWhere(t *testing.T) {
+	b := Builder{Dialect: DialectPostgres, Columns: testColumns}
+
+	where, args, err := b.Where([]query.Filter{})
+	assert.Nil(t, err)
+	assert.Equal(t, "", where)
+	assert.Empty(t, args)
+
+	where, args, err = b.Where([]query.Filter{{Field: "price", Operation: query.FilterOperatorEqual, Value: "1"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "WHERE price = $1", where)
+	assert.Equal(t, []interface{}{"1"}, args)
+}
+
+func TestBuilderSelect(t *testing.T) {
+	b := Builder{Dialect: DialectPostgres, Columns: testColumns}
+
+	got, err := b.Select(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "*", got)
+
+	got, err = b.Select([]string{"name", "price"})
+	assert.Nil(t, err)
+	assert.Equal(t, "p.name, price", got)
+
+	_, err = b.Select([]string{"password"})
+	assert.True(t, errors.Is(err, ErrUnknownField))
+}
+
+func TestBuilderBuildQuery(t *testing.T) {
+	expect := assert.New(t)
+	b := Builder{Dialect: DialectPostgres, Columns: testColumns}
+
+	expr := query.And(
+		query.Or(
+			query.Leaf(query.Filter{Field: "name", Operation: query.FilterOperatorEqual, Value: "a"}),
+			query.Leaf(query.Filter{Field: "price", Operation: query.FilterOperatorLessThan, Value: "5"}),
+		),
+		query.Not(query.Leaf(query.Filter{Field: "tags", Operation: query.FilterOperatorIn, Value: "x;y"})),
+	)
+
+	got, err := b.BuildQuery(query.Query{
+		Expr:       &expr,
+		Orders:     []query.Order{{Field: "price", Asc: true}},
+		Pagination: query.Paginable{Limit: 10},
+	})
+	expect.Nil(err)
+	expect.Equal("WHERE ((p.name = $1 OR price < $2) AND NOT (tag IN ($3, $4))) ORDER BY price ASC LIMIT $5 OFFSET $6", got.String())
+	expect.Equal([]interface{}{"a", "5", "x", "y", 10, 0}, got.Args)
+
+	got, err = b.BuildQuery(query.Query{
+		Filters:    []query.Filter{{Field: "name", Operation: query.FilterOperatorEqual, Value: "a"}},
+		Pagination: query.Paginable{Limit: 10},
+	})
+	expect.Nil(err)
+	expect.Equal("WHERE p.name = $1 LIMIT $2 OFFSET $3", got.String())
+
+	_, _, err = b.WhereExpr(query.Not(query.Leaf(query.Filter{Field: "password", Operation: query.FilterOperatorEqual, Value: "a"})))
+	expect.True(errors.Is(err, ErrUnknownField))
+
+	_, err = b.BuildQuery(query.Query{Expr: &query.FilterExpr{Kind: query.FilterExprLeaf}})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, _, err = b.WhereExpr(query.FilterExpr{Kind: query.FilterExprNot})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	empty := query.And()
+	_, err = b.BuildQuery(query.Query{Expr: &empty, Pagination: query.Paginable{Limit: 10}})
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+
+	_, _, err = b.WhereExpr(query.Not(query.Or()))
+	expect.True(errors.Is(err, query.ErrInvalidExpr))
+}
+
+// Fixtures shared with the query.Apply tests, which assert the rows each SQL selects
+type applyFixtures struct {
+	Columns map[string]string `json:"columns"`
+	Cases   []struct {
+		Name     string `json:"name"`
+		Query    string `json:"query"`
+		Grouping bool   `json:"grouping"`
+		SQL      string `json:"sql"`
+	} `json:"cases"`
+}
+
+func TestBuilderBuildQueryWithApplyFixtures(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", "products.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fixtures applyFixtures
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatal(err)
+	}
+
+	b := Builder{Dialect: DialectPostgres, Columns: fixtures.Columns}
+
+	for _, tt := range fixtures.Cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			q, err := query.ParseValues(query.ParseQueryString(tt.Query), query.Options{Strict: true, Grouping: tt.Grouping})
+			assert.Nil(t, err)
+
+			got, err := b.BuildQuery(q)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.SQL, got.String())
+		})
+	}
+}
+
+func TestBuilderBuildQueryWithAliases(t *testing.T) {
+	expect := assert.New(t)
+
+	aliases := query.Aliases{
+		"createdAt":     {Name: "created_at"},
+		"customer.name": {Name: "c.full_name"},
+		"status": {Name: "status_id", Transform: func(v interface{}) (interface{}, error) {
+			return map[interface{}]int{"open": 1, "closed": 2}[v], nil
+		}},
+	}
+
+	q, err := query.ParseValues(url.Values{
+		"filters": {"customer.name[eq]a,status[in]open;closed"},
+		"order":   {"createdAt:desc"},
+	}, query.Options{})
+	expect.Nil(err)
+
+	rewritten, err := aliases.Rewrite(q)
+	expect.Nil(err)
+
+	got, err := Builder{Dialect: DialectPostgres, Columns: aliases.Names()}.BuildQuery(rewritten)
+	expect.Nil(err)
+	expect.Equal("WHERE c.full_name = $1 AND status_id IN ($2, $3) ORDER BY created_at DESC LIMIT $4 OFFSET $5", got.String())
+	expect.Equal([]interface{}{"a", 1, 2, 10, 0}, got.Args)
+
+	_, err = Builder{Dialect: DialectPostgres, Columns: aliases.Names()}.BuildQuery(q)
+	expect.True(errors.Is(err, ErrUnknownField))
+}
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// A segment of a query value and its byte offset on the value
+type segment struct {
+	text     string
+	position int
+}
+
+func (s segment) error(key QueryKey, err error) *SegmentError {
+	return &SegmentError{Key: key, Segment: s.text, Position: s.position, Err: err}
+}
+
+func splitSegments(value string, sep QueryParamSeparator) []segment {
+	segments := []segment{}
+	if value == "" {
+		return segments
+	}
+
+	position := 0
+
+	for _, text := range splitUnescaped(value, sep, true) {
+		segments = append(segments, segment{text: text, position: position})
+		position += len(text) + len(sep)
+	}
+
+	return segments
+}
+
+// Same as GetFilterFromQuery, but instead of silently dropping malformed filters
+// returns a ParseErrors describing each of them along with the valid ones
+func GetFilterFromQueryStrict(c *fiber.Ctx) ([]Filter, error) {
+	queryParams := queryArgsToValues(c)
+	return getFilterFromQueryStrict(queryParams)
+}
+
+func getFilterFromQueryStrict(queryParams url.Values) ([]Filter, error) {
+	values, ok := queryParams[string(QueryKeyFilters)]
+	if !ok {
+		return []Filter{}, nil
+	}
+
+	filters, errs := parseFilters(values, nil, true)
+	return filters, errs.orNil()
+}
+
+// Same as GetOrderFromQuery, but instead of silently dropping malformed orders
+// returns a ParseErrors describing each of them along with the valid ones
+func GetOrderFromQueryStrict(c *fiber.Ctx) ([]Order, error) {
+	queryParams := queryArgsToValues(c)
+	return getOrderFromQueryStrict(queryParams)
+}
+
+func getOrderFromQueryStrict(queryParams url.Values) ([]Order, error) {
+	values, ok := queryParams[string(QueryKeyOrder)]
+	if !ok {
+		return []Order{}, nil
+	}
+
+	orders, errs := parseOrders(values, nil, true)
+	return orders, errs.orNil()
+}
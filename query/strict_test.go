@@ -0,0 +1,144 @@
+package query
+
+import (
+	"errors"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFilterFromQueryStrict(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []Filter
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should return empty slice when query params has no filters",
+			args: args{queryParams: url.Values{}},
+			want: []Filter{},
+		},
+		{
+			name: "should return empty slice when query params has empty filters",
+			args: args{queryParams: url.Values{"filters": {""}}},
+			want: []Filter{},
+		},
+		{
+			name: "should return Filter slice when every filter is valid",
+			args: args{queryParams: url.Values{"filters": {"a[eq]b,c[gt]1"}}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorEqual, Value: "b"},
+				{Field: "c", Operation: FilterOperatorGreaterThan, Value: "1"},
+			},
+		},
+		{
+			name: "should report every malformed filter with its position",
+			args: args{queryParams: url.Values{"filters": {"price[gtt]10,a[eq]b,[eq]c,d[eq],e[eqf,g]eq[h"}}},
+			want: []Filter{
+				{Field: "a", Operation: FilterOperatorEqual, Value: "b"},
+			},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyFilters, Segment: "price[gtt]10", Position: 0, Err: ErrUnknownOperator},
+				{Key: QueryKeyFilters, Segment: "[eq]c", Position: 20, Err: ErrInvalidField},
+				{Key: QueryKeyFilters, Segment: "d[eq]", Position: 26, Err: ErrEmptyValue},
+				{Key: QueryKeyFilters, Segment: "e[eqf", Position: 32, Err: ErrMissingBracket},
+				{Key: QueryKeyFilters, Segment: "g]eq[h", Position: 38, Err: ErrMissingBracket},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getFilterFromQueryStrict(tt.args.queryParams)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, err)
+		})
+	}
+}
+
+func TestGetOrderFromQueryStrict(t *testing.T) {
+	type args struct {
+		queryParams url.Values
+	}
+	tests := []struct {
+		name     string
+		args     args
+		want     []Order
+		wantErrs []*SegmentError
+	}{
+		{
+			name: "should return empty slice when query params has no order",
+			args: args{queryParams: url.Values{}},
+			want: []Order{},
+		},
+		{
+			name: "should return Order slice when every order is valid",
+			args: args{queryParams: url.Values{"order": {"a:asc,b:DESC"}}},
+			want: []Order{
+				{Field: "a", Asc: true},
+				{Field: "b", Asc: false},
+			},
+		},
+		{
+			name: "should report every malformed order with its position",
+			args: args{queryParams: url.Values{"order": {"a:asc,b,c:up,.:desc"}}},
+			want: []Order{
+				{Field: "a", Asc: true},
+			},
+			wantErrs: []*SegmentError{
+				{Key: QueryKeyOrder, Segment: "b", Position: 6, Err: ErrMissingDirection},
+				{Key: QueryKeyOrder, Segment: "c:up", Position: 8, Err: ErrInvalidDirection},
+				{Key: QueryKeyOrder, Segment: ".:desc", Position: 13, Err: ErrInvalidField},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getOrderFromQueryStrict(tt.args.queryParams)
+			assert.True(t, reflect.DeepEqual(tt.want, got), "got: %v, want: %v", got, tt.want)
+			assertSegmentErrors(t, tt.wantErrs, err)
+		})
+	}
+}
+
+func assertSegmentErrors(t *testing.T, want []*SegmentError, err error) {
+	t.Helper()
+
+	if len(want) == 0 {
+		assert.Nil(t, err)
+		return
+	}
+
+	var errs ParseErrors
+	if !assert.True(t, errors.As(err, &errs), "got: %v, want ParseErrors", err) {
+		return
+	}
+
+	if !assert.Len(t, errs, len(want)) {
+		return
+	}
+
+	for i, w := range want {
+		assert.Equal(t, w.Key, errs[i].Key)
+		assert.Equal(t, w.Segment, errs[i].Segment)
+		assert.Equal(t, w.Position, errs[i].Position)
+		assert.True(t, errors.Is(errs[i], w.Err), "got: %v, want: %v", errs[i].Err, w.Err)
+	}
+}
+
+func TestParseErrorsIs(t *testing.T) {
+	errs := ParseErrors{
+		{Key: QueryKeyFilters, Segment: "a[x]b", Err: ErrUnknownOperator},
+	}
+
+	assert.True(t, errors.Is(errs, ErrUnknownOperator))
+	assert.False(t, errors.Is(errs, ErrEmptyValue))
+	assert.Equal(t, `filters: "a[x]b" at position 0: unknown operator`, errs.Error())
+}
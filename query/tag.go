@@ -0,0 +1,243 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrInvalidQueryTag = errors.New("invalid query tag")
+
+// Struct tag read by SchemaFor, eg: `json:"price" query:"filter=gt|lt|eq,sort"`. Its options are separated by commas:
+//   - filter: the field accepts every operator its type supports but regex, or only the listed ones, eg: "filter=gt|lt"
+//   - sort: the field may be used on order
+//   - select: the field may be requested on fields
+//   - include: the relation may be included, for struct fields
+//   - type: overrides the type inferred from the Go type, eg: "type=uuid"
+//   - enum: the accepted values of an enum field, eg: "enum=open|closed"
+//
+// The tag "-" skips the field and its nested fields.
+const QueryTag = "query"
+
+// Separates the values of the filter and enum tag options
+const queryTagListSeparator = "|"
+
+// Every operator the filter tag option enables when it lists none
+var filterOperators = []FilterOperator{
+	FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIn, FilterOperatorNotIn,
+	FilterOperatorLessThan, FilterOperatorLessThanOrEqual,
+	FilterOperatorGreaterThan, FilterOperatorGretherThanOrEqual, FilterOperatorBetween,
+	FilterOperatorStartsWith, FilterOperatorEndsWith, FilterOperatorContains,
+	FilterOperatorEqualFold, FilterOperatorContainsFold,
+	FilterOperatorIsNull, FilterOperatorNotNull,
+}
+
+type cachedSchema struct {
+	schema *Schema
+	err    error
+}
+
+// Schemas built by SchemaFor, keyed by reflect.Type
+var schemas sync.Map
+
+// Builds the schema of T from the query tags of its fields, named after their json names. Nested struct
+// fields are named with their dotted path, eg: "customer.name". The schema is built once per type and
+// shared by every caller, so it must not be changed.
+func SchemaFor[T any]() (*Schema, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	if cached, ok := schemas.Load(t); ok {
+		c := cached.(cachedSchema)
+		return c.schema, c.err
+	}
+
+	schema, err := schemaOf(t)
+	schemas.Store(t, cachedSchema{schema: schema, err: err})
+	return schema, err
+}
+
+// Same as Parse, but strict and validated against the schema SchemaFor builds for T
+func ParseFor[T any](ctx *fiber.Ctx) (Query, error) {
+	return ParseForWithOptions[T](ctx, Options{Strict: true})
+}
+
+// Same as ParseFor, but with the given options. Options.Schema is replaced by the schema of T.
+func ParseForWithOptions[T any](ctx *fiber.Ctx, opts Options) (Query, error) {
+	schema, err := SchemaFor[T]()
+	if err != nil {
+		return Query{}, err
+	}
+
+	opts.Schema = schema
+	return Parse(ctx, opts)
+}
+
+func schemaOf(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s is not a struct", ErrInvalidQueryTag, t)
+	}
+
+	schema := &Schema{Fields: map[string]Field{}}
+	if err := addTaggedFields(schema, t, "", map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Adds the tagged fields of the struct t, and of its nested structs, to the schema. Visiting holds the
+// structs being walked, so self referencing structs are not walked forever.
+func addTaggedFields(schema *Schema, t reflect.Type, prefix string, visiting map[reflect.Type]bool) error {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		tag, tagged := sf.Tag.Lookup(QueryTag)
+
+		// same as encoding/json, the fields of embedded structs are promoted even when their type is unexported
+		if (!sf.IsExported() && !(sf.Anonymous && jsonName == "")) || jsonName == "-" || tag == "-" {
+			continue
+		}
+
+		ft := sf.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		// the structs of slices are walked too, eg: the product of "items.product" on Items []*Item
+		et := ft
+		for et.Kind() == reflect.Pointer || et.Kind() == reflect.Slice || et.Kind() == reflect.Array {
+			et = et.Elem()
+		}
+		nested := et.Kind() == reflect.Struct && et != timeType
+
+		if sf.Anonymous && jsonName == "" {
+			if nested && et == ft && !visiting[ft] {
+				if err := addTaggedFields(schema, ft, prefix, visiting); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		name := jsonName
+		if name == "" {
+			name = sf.Name
+		}
+		path := prefix + name
+
+		if tagged {
+			if err := addTaggedField(schema, path, ft, tag); err != nil {
+				return err
+			}
+		}
+
+		if nested && !visiting[et] {
+			if err := addTaggedFields(schema, et, path+FieldPathSeparator, visiting); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// eg: "price", float64 and "filter=gt|lt,sort" -> Field{Operators: [gt, lt], Sortable: true, Type: FieldTypeFloat}
+func addTaggedField(schema *Schema, path string, t reflect.Type, tag string) error {
+	field := Field{Type: fieldTypeOf(t)}
+	var operators []FilterOperator
+	filterable := false
+
+	for _, option := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+
+		switch key {
+		case "":
+		case "filter":
+			filterable = true
+			for _, op := range strings.Split(value, queryTagListSeparator) {
+				if op != "" {
+					operators = append(operators, FilterOperator(op))
+				}
+			}
+		case "sort":
+			field.Sortable = true
+		case "select":
+			field.Selectable = true
+		case "include":
+			schema.Includes = append(schema.Includes, path)
+		case "type":
+			field.Type = FieldType(value)
+		case "enum":
+			field.Type = FieldTypeEnum
+			field.Enum = strings.Split(value, queryTagListSeparator)
+		default:
+			return fmt.Errorf("%w: %q on %q: unknown option %q", ErrInvalidQueryTag, tag, path, key)
+		}
+	}
+
+	// every type supports eq, so only unknown types are rejected
+	if field.Type != "" && !field.Type.Supports(FilterOperatorEqual) {
+		return fmt.Errorf("%w: %q on %q: unknown type %q", ErrInvalidQueryTag, tag, path, field.Type)
+	}
+
+	if !filterable {
+		schema.Fields[path] = field
+		return nil
+	}
+
+	if field.Type == "" {
+		return fmt.Errorf("%w: %q on %q: can't filter %s fields without a type option", ErrInvalidQueryTag, tag, path, t)
+	}
+
+	if len(operators) == 0 {
+		for _, op := range filterOperators {
+			if field.Type.Supports(op) {
+				operators = append(operators, op)
+			}
+		}
+	}
+
+	for _, op := range operators {
+		if !field.Type.Supports(op) {
+			return fmt.Errorf("%w: %q on %q: %q can't be used on %s fields", ErrInvalidQueryTag, tag, path, op, field.Type)
+		}
+	}
+
+	field.Operators = operators
+	schema.Fields[path] = field
+	return nil
+}
+
+// The FieldType of a Go type, empty when it has none
+func fieldTypeOf(t reflect.Type) FieldType {
+	switch {
+	case t == timeType:
+		return FieldTypeTime
+	case t == decimalType:
+		return FieldTypeDecimal
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return FieldTypeString
+	case reflect.Bool:
+		return FieldTypeBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldTypeInt
+	case reflect.Float32, reflect.Float64:
+		return FieldTypeFloat
+	}
+
+	return ""
+}
@@ -0,0 +1,192 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+type taggedAudit struct {
+	CreatedAt time.Time  `json:"createdAt" query:"filter=gt|lt,sort"`
+	DeletedAt *time.Time `json:"deletedAt" query:"filter=isNull|notNull"`
+}
+
+type taggedCustomer struct {
+	Name     string          `json:"name" query:"filter=eq|contains,select"`
+	Referrer *taggedCustomer `json:"referrer" query:"include"`
+}
+
+type taggedProduct struct {
+	taggedAudit
+	ID       string          `json:"id" query:"filter=eq|in,type=uuid"`
+	Price    float64         `json:"price" query:"filter=gt|lt|eq,sort"`
+	Cost     Decimal         `json:"cost" query:"filter"`
+	Status   string          `json:"status" query:"enum=open|closed,filter=eq"`
+	Active   bool            `json:"active" query:"filter"`
+	Customer *taggedCustomer `json:"customer" query:"include,select"`
+	Secret   string          `json:"-" query:"filter"`
+	Internal string          `query:"-"`
+	Untagged int             `json:"untagged"`
+}
+
+type taggedLine struct {
+	Quantity int            `json:"quantity" query:"filter=gt"`
+	Product  *taggedProduct `json:"product" query:"include"`
+}
+
+type taggedOrder struct {
+	Items []*taggedLine `json:"items" query:"include"`
+	Notes []taggedAudit `json:"notes"`
+}
+
+type taggedBase struct {
+	ID int `json:"id" query:"filter=eq|gt,sort"`
+}
+
+type taggedItem struct {
+	taggedBase
+	Name string `json:"name"`
+}
+
+func TestSchemaFor(t *testing.T) {
+	expect := assert.New(t)
+
+	schema, err := SchemaFor[taggedProduct]()
+	expect.Nil(err)
+	expect.Equal(map[string]Field{
+		"createdAt": {Operators: []FilterOperator{FilterOperatorGreaterThan, FilterOperatorLessThan}, Sortable: true, Type: FieldTypeTime},
+		"deletedAt": {Operators: []FilterOperator{FilterOperatorIsNull, FilterOperatorNotNull}, Type: FieldTypeTime},
+		"id":        {Operators: []FilterOperator{FilterOperatorEqual, FilterOperatorIn}, Type: FieldTypeUUID},
+		"price":     {Operators: []FilterOperator{FilterOperatorGreaterThan, FilterOperatorLessThan, FilterOperatorEqual}, Sortable: true, Type: FieldTypeFloat},
+		"cost":      {Operators: comparisonOperators, Type: FieldTypeDecimal},
+		"status":    {Operators: []FilterOperator{FilterOperatorEqual}, Type: FieldTypeEnum, Enum: []string{"open", "closed"}},
+		"active": {
+			Operators: []FilterOperator{FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIsNull, FilterOperatorNotNull},
+			Type:      FieldTypeBool,
+		},
+		"customer":          {Selectable: true},
+		"customer.name":     {Operators: []FilterOperator{FilterOperatorEqual, FilterOperatorContains}, Selectable: true, Type: FieldTypeString},
+		"customer.referrer": {},
+	}, schema.Fields)
+	expect.Equal([]string{"customer", "customer.referrer"}, schema.Includes)
+
+	cached, err := SchemaFor[*taggedProduct]()
+	expect.Nil(err)
+	expect.Equal(schema, cached)
+
+	again, err := SchemaFor[taggedProduct]()
+	expect.Nil(err)
+	expect.Same(schema, again)
+}
+
+func TestSchemaForErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema func() (*Schema, error)
+	}{
+		{
+			name: "should reject unknown options",
+			schema: SchemaFor[struct {
+				Price int `query:"filter,sortable"`
+			}],
+		},
+		{
+			name: "should reject unknown operators",
+			schema: SchemaFor[struct {
+				Price int `query:"filter=gtt"`
+			}],
+		},
+		{
+			name: "should reject operators the type does not support",
+			schema: SchemaFor[struct {
+				Active bool `query:"filter=gt"`
+			}],
+		},
+		{
+			name: "should reject unknown types",
+			schema: SchemaFor[struct {
+				ID string `query:"type=guid"`
+			}],
+		},
+		{
+			name: "should reject filters on fields without a type",
+			schema: SchemaFor[struct {
+				Tags []string `query:"filter"`
+			}],
+		},
+		{
+			name:   "should reject types that are not structs",
+			schema: SchemaFor[[]taggedProduct],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.schema()
+			assert.True(t, errors.Is(err, ErrInvalidQueryTag), "got: %v", err)
+		})
+	}
+}
+
+func TestParseFor(t *testing.T) {
+	expect := assert.New(t)
+
+	app := fiber.New()
+	fctx := &fasthttp.RequestCtx{}
+	fctx.Request.SetRequestURI("/products?filters=price[gt]10,customer.name[contains]a&order=createdAt:desc&fields=customer")
+	ctx := app.AcquireCtx(fctx)
+	defer app.ReleaseCtx(ctx)
+
+	got, err := ParseFor[taggedProduct](ctx)
+	expect.Nil(err)
+	expect.Equal([]Filter{
+		{Field: "price", Operation: FilterOperatorGreaterThan, Value: "10", Typed: float64(10)},
+		{Field: "customer.name", Operation: FilterOperatorContains, Value: "a", Typed: "a"},
+	}, got.Filters)
+	expect.Equal([]Order{{Field: "createdAt", Asc: false}}, got.Orders)
+	expect.Equal([]string{"customer"}, got.Fields)
+
+	fctx.Request.SetRequestURI("/products?filters=price[contains]1,untagged[eq]1&order=price")
+	_, err = ParseFor[taggedProduct](ctx)
+	expect.True(errors.Is(err, ErrOperatorNotAllowed))
+	expect.True(errors.Is(err, ErrFieldNotFilterable))
+	expect.True(errors.Is(err, ErrMissingDirection))
+
+	_, err = ParseForWithOptions[taggedProduct](ctx, Options{})
+	expect.False(errors.Is(err, ErrMissingDirection))
+}
+
+func TestSchemaForEmbeddedStructs(t *testing.T) {
+	expect := assert.New(t)
+
+	schema, err := SchemaFor[taggedItem]()
+	expect.Nil(err)
+
+	q, err := ParseValues(ParseQueryString("filters=id[gt]1&order=id:asc"), Options{Schema: schema})
+	expect.Nil(err)
+
+	items := []taggedItem{{taggedBase{1}, "a"}, {taggedBase{2}, "b"}, {taggedBase{3}, "c"}}
+	expect.Equal(items[1:], Apply(items, q), "should filter and sort on promoted fields of unexported structs")
+
+	cursor, err := NextCursor(items[1], q.Orders)
+	expect.Nil(err)
+	expect.Equal([]interface{}{int64(2)}, cursor.Values)
+}
+
+func TestSchemaForSlices(t *testing.T) {
+	expect := assert.New(t)
+
+	schema, err := SchemaFor[taggedOrder]()
+	expect.Nil(err)
+	expect.Equal([]string{"items", "items.product", "items.product.customer", "items.product.customer.referrer"}, schema.Includes)
+	expect.Equal(Field{Operators: []FilterOperator{FilterOperatorGreaterThan}, Type: FieldTypeInt}, schema.Fields["items.quantity"])
+	expect.Contains(schema.Fields, "notes.createdAt")
+
+	q, err := ParseValues(ParseQueryString("include=items.product"), Options{Schema: schema})
+	expect.Nil(err)
+	expect.True(q.Includes.Has("items.product"))
+}
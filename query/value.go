@@ -0,0 +1,231 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidFilterValue  = errors.New("invalid filter value")
+	ErrOperatorUnsupported = errors.New("operator is not supported by field type")
+)
+
+type FieldType string
+
+const (
+	FieldTypeString  FieldType = "string"
+	FieldTypeInt     FieldType = "int"
+	FieldTypeFloat   FieldType = "float"
+	FieldTypeDecimal FieldType = "decimal"
+	FieldTypeBool    FieldType = "bool"
+	FieldTypeTime    FieldType = "time" // RFC3339, eg: "2022-01-01T10:00:00Z"
+	FieldTypeDate    FieldType = "date" // eg: "2022-01-01"
+	FieldTypeUUID    FieldType = "uuid"
+	FieldTypeEnum    FieldType = "enum" // one of the values declared on Field.Enum
+)
+
+const DateLayout = "2006-01-02"
+
+// Decimal holds the exact string representation of a decimal number, eg: "10.50",
+// so it can be handed to a database without floating point rounding
+type Decimal string
+
+var (
+	decimalRegex = regexp.MustCompile(`^[+-]?\d+(\.\d+)?$`)
+	uuidRegex    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+var (
+	comparisonOperators = []FilterOperator{
+		FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIn, FilterOperatorNotIn,
+		FilterOperatorLessThan, FilterOperatorLessThanOrEqual,
+		FilterOperatorGreaterThan, FilterOperatorGretherThanOrEqual,
+		FilterOperatorBetween, FilterOperatorIsNull, FilterOperatorNotNull,
+	}
+	equalityOperators = []FilterOperator{
+		FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIn, FilterOperatorNotIn,
+		FilterOperatorIsNull, FilterOperatorNotNull,
+	}
+)
+
+// Returns whether filtering a field of type t with op makes sense,
+// eg: "startsWith" is only supported by strings and "gt" is not supported by booleans
+func (t FieldType) Supports(op FilterOperator) bool {
+	var supported []FilterOperator
+
+	switch t {
+	case "", FieldTypeString:
+		return op.IsValid() || op == FilterOperatorRegex
+	case FieldTypeInt, FieldTypeFloat, FieldTypeDecimal, FieldTypeTime, FieldTypeDate:
+		supported = comparisonOperators
+	case FieldTypeUUID, FieldTypeEnum:
+		supported = equalityOperators
+	case FieldTypeBool:
+		supported = []FilterOperator{FilterOperatorEqual, FilterOperatorNotEqual, FilterOperatorIsNull, FilterOperatorNotNull}
+	}
+
+	for _, o := range supported {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits the filter value into the values of a list operator, eg: "tag1;tag2" -> ["tag1", "tag2"].
+// Escaped separators are part of the values, eg: "a\;b;c" -> ["a;b", "c"], see JoinValues.
+func (f Filter) Values() []string {
+	values := splitUnescaped(f.Value, QueryParamSeparatorArray, false)
+
+	for i, v := range values {
+		values[i] = unescape(v, false)
+	}
+
+	return values
+}
+
+// The value converted to the schema field type, or the raw value when parsed without a schema
+func (f Filter) TypedValue() interface{} {
+	if f.Typed != nil {
+		return f.Typed
+	}
+	return f.Value
+}
+
+// Same as TypedValue, but for the values of a list operator
+func (f Filter) TypedValues() []interface{} {
+	list := []interface{}{}
+
+	if f.Typed != nil {
+		typed := reflect.ValueOf(f.Typed)
+		if typed.Kind() == reflect.Slice {
+			for i := 0; i < typed.Len(); i++ {
+				list = append(list, typed.Index(i).Interface())
+			}
+			return list
+		}
+	}
+
+	for _, v := range f.Values() {
+		list = append(list, v)
+	}
+	return list
+}
+
+// Converts the filter value to the field type, returning a typed slice for list operators, eg: []int64,
+// and nil for operators that take no value
+func (f *Field) coerce(filter Filter) (interface{}, error) {
+	switch filter.Operation.Arity() {
+	case ArityNone:
+		return nil, nil
+	case ArityTwo, ArityMany:
+		return f.coerceList(filter.Values())
+	}
+
+	return f.coerceValue(filter.Value)
+}
+
+func (f *Field) coerceValue(value string) (interface{}, error) {
+	switch f.Type {
+	case FieldTypeInt:
+		return parseInt(value)
+	case FieldTypeFloat:
+		return parseFloat(value)
+	case FieldTypeDecimal:
+		return parseDecimal(value)
+	case FieldTypeBool:
+		return strconv.ParseBool(value)
+	case FieldTypeTime:
+		return parseTime(value)
+	case FieldTypeDate:
+		return parseDate(value)
+	case FieldTypeUUID:
+		return parseUUID(value)
+	case FieldTypeEnum:
+		return f.parseEnum(value)
+	}
+
+	return value, nil
+}
+
+func (f *Field) coerceList(values []string) (interface{}, error) {
+	switch f.Type {
+	case FieldTypeInt:
+		return parseList(values, parseInt)
+	case FieldTypeFloat:
+		return parseList(values, parseFloat)
+	case FieldTypeDecimal:
+		return parseList(values, parseDecimal)
+	case FieldTypeBool:
+		return parseList(values, strconv.ParseBool)
+	case FieldTypeTime:
+		return parseList(values, parseTime)
+	case FieldTypeDate:
+		return parseList(values, parseDate)
+	case FieldTypeUUID:
+		return parseList(values, parseUUID)
+	case FieldTypeEnum:
+		return parseList(values, f.parseEnum)
+	}
+
+	return values, nil
+}
+
+func parseList[T any](values []string, parse func(string) (T, error)) ([]T, error) {
+	list := make([]T, 0, len(values))
+
+	for _, v := range values {
+		t, err := parse(v)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, t)
+	}
+
+	return list, nil
+}
+
+func parseInt(value string) (int64, error) {
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseFloat(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+func parseDecimal(value string) (Decimal, error) {
+	if !decimalRegex.MatchString(value) {
+		return "", errors.New("not a decimal")
+	}
+	return Decimal(value), nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	return time.Parse(time.RFC3339, value)
+}
+
+func parseDate(value string) (time.Time, error) {
+	return time.Parse(DateLayout, value)
+}
+
+func parseUUID(value string) (string, error) {
+	if !uuidRegex.MatchString(value) {
+		return "", errors.New("not a uuid")
+	}
+	return strings.ToLower(value), nil
+}
+
+func (f *Field) parseEnum(value string) (string, error) {
+	for _, e := range f.Enum {
+		if e == value {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("not one of %s", strings.Join(f.Enum, ", "))
+}
@@ -0,0 +1,186 @@
+package query
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldTypeSupports(t *testing.T) {
+	type args struct {
+		fieldType FieldType
+		operator  FilterOperator
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "should return true when string field uses startsWith",
+			args: args{fieldType: FieldTypeString, operator: FilterOperatorStartsWith},
+			want: true,
+		},
+		{
+			name: "should return true when untyped field uses contains",
+			args: args{fieldType: "", operator: FilterOperatorContains},
+			want: true,
+		},
+		{
+			name: "should return true when int field uses gt",
+			args: args{fieldType: FieldTypeInt, operator: FilterOperatorGreaterThan},
+			want: true,
+		},
+		{
+			name: "should return false when int field uses startsWith",
+			args: args{fieldType: FieldTypeInt, operator: FilterOperatorStartsWith},
+			want: false,
+		},
+		{
+			name: "should return false when bool field uses gt",
+			args: args{fieldType: FieldTypeBool, operator: FilterOperatorGreaterThan},
+			want: false,
+		},
+		{
+			name: "should return true when bool field uses eq",
+			args: args{fieldType: FieldTypeBool, operator: FilterOperatorEqual},
+			want: true,
+		},
+		{
+			name: "should return false when enum field uses lt",
+			args: args{fieldType: FieldTypeEnum, operator: FilterOperatorLessThan},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.args.fieldType.Supports(tt.args.operator)
+			assert.Equal(t, tt.want, got, "got: %v, want: %v", got, tt.want)
+		})
+	}
+}
+
+func TestSchemaCoerceFilter(t *testing.T) {
+	schema := &Schema{
+		Fields: map[string]Field{
+			"age":       {Type: FieldTypeInt, Operators: []FilterOperator{FilterOperatorGreaterThan, FilterOperatorIn}},
+			"price":     {Type: FieldTypeFloat, Operators: []FilterOperator{FilterOperatorLessThan}},
+			"total":     {Type: FieldTypeDecimal, Operators: []FilterOperator{FilterOperatorEqual}},
+			"active":    {Type: FieldTypeBool, Operators: []FilterOperator{FilterOperatorEqual, FilterOperatorGreaterThan}},
+			"createdAt": {Type: FieldTypeTime, Operators: []FilterOperator{FilterOperatorGretherThanOrEqual}},
+			"birthday":  {Type: FieldTypeDate, Operators: []FilterOperator{FilterOperatorEqual}},
+			"id":        {Type: FieldTypeUUID, Operators: []FilterOperator{FilterOperatorEqual}},
+			"status":    {Type: FieldTypeEnum, Enum: []string{"open", "closed"}, Operators: []FilterOperator{FilterOperatorIn}},
+			"tags":      {Operators: []FilterOperator{FilterOperatorIn}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		filter  Filter
+		want    interface{}
+		wantErr error
+	}{
+		{
+			name:   "should convert int value",
+			filter: Filter{Field: "age", Operation: FilterOperatorGreaterThan, Value: "18"},
+			want:   int64(18),
+		},
+		{
+			name:   "should convert int list value",
+			filter: Filter{Field: "age", Operation: FilterOperatorIn, Value: "18;21"},
+			want:   []int64{18, 21},
+		},
+		{
+			name:    "should return error when int list has an invalid value",
+			filter:  Filter{Field: "age", Operation: FilterOperatorIn, Value: "18;abc"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should convert float value",
+			filter: Filter{Field: "price", Operation: FilterOperatorLessThan, Value: "10.5"},
+			want:   10.5,
+		},
+		{
+			name:    "should return error when float value is invalid",
+			filter:  Filter{Field: "price", Operation: FilterOperatorLessThan, Value: "abc"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should convert decimal value",
+			filter: Filter{Field: "total", Operation: FilterOperatorEqual, Value: "10.50"},
+			want:   Decimal("10.50"),
+		},
+		{
+			name:    "should return error when decimal value is invalid",
+			filter:  Filter{Field: "total", Operation: FilterOperatorEqual, Value: "1e10"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should convert bool value",
+			filter: Filter{Field: "active", Operation: FilterOperatorEqual, Value: "true"},
+			want:   true,
+		},
+		{
+			name:    "should return error when operator is not supported by the field type",
+			filter:  Filter{Field: "active", Operation: FilterOperatorGreaterThan, Value: "true"},
+			wantErr: ErrOperatorUnsupported,
+		},
+		{
+			name:   "should convert time value",
+			filter: Filter{Field: "createdAt", Operation: FilterOperatorGretherThanOrEqual, Value: "2022-01-01T10:00:00Z"},
+			want:   time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "should convert date value",
+			filter: Filter{Field: "birthday", Operation: FilterOperatorEqual, Value: "2022-01-01"},
+			want:   time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "should return error when date value is invalid",
+			filter:  Filter{Field: "birthday", Operation: FilterOperatorEqual, Value: "01/01/2022"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should convert uuid value to lower case",
+			filter: Filter{Field: "id", Operation: FilterOperatorEqual, Value: "A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11"},
+			want:   "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11",
+		},
+		{
+			name:    "should return error when uuid value is invalid",
+			filter:  Filter{Field: "id", Operation: FilterOperatorEqual, Value: "123"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should convert enum list value",
+			filter: Filter{Field: "status", Operation: FilterOperatorIn, Value: "open;closed"},
+			want:   []string{"open", "closed"},
+		},
+		{
+			name:    "should return error when enum value is not declared",
+			filter:  Filter{Field: "status", Operation: FilterOperatorIn, Value: "open;pending"},
+			wantErr: ErrInvalidFilterValue,
+		},
+		{
+			name:   "should split string list value",
+			filter: Filter{Field: "tags", Operation: FilterOperatorIn, Value: "tag1;tag2"},
+			want:   []string{"tag1", "tag2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := schema.CoerceFilter(tt.filter)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got: %v, want: %v", err, tt.wantErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, reflect.DeepEqual(tt.want, got.Typed), "got: %v, want: %v", got.Typed, tt.want)
+		})
+	}
+}